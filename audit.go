@@ -0,0 +1,160 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TopicAccuracySample is a topic's accuracy% together with the IDs of
+// a few attempts behind it, for spot checks.
+type TopicAccuracySample struct {
+	Accuracy         float64
+	SampleAttemptIDs []uint
+}
+
+// CalculateUserTopicAccuracyWithSamples returns per-topic accuracy% plus
+// up to samplesPerTopic attempt IDs per topic. Samples are the most
+// recent attempts, alternating correct and incorrect ones (newest of
+// each first) so both outcomes are represented when both exist.
+func CalculateUserTopicAccuracyWithSamples(db *gorm.DB, userID uuid.UUID, samplesPerTopic int) (map[string]TopicAccuracySample, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]TopicAccuracySample, len(counts))
+	for _, c := range counts {
+		results[c.Topic] = TopicAccuracySample{Accuracy: c.accuracy()}
+	}
+	if samplesPerTopic <= 0 {
+		return results, nil
+	}
+
+	type Sample struct {
+		ID        uint
+		Topic     string
+		IsCorrect bool
+	}
+
+	// Rank each attempt within its (topic, outcome) so at most
+	// samplesPerTopic of each outcome leave the database.
+	ranked := userAttempts(db, userID).
+		Select(`
+			question_attempts.id          AS id,
+			questions.topic               AS topic,
+			question_attempts.is_correct  AS is_correct,
+			ROW_NUMBER() OVER (
+				PARTITION BY questions.topic, question_attempts.is_correct
+				ORDER BY ` + attemptOrderDesc + `
+			)                             AS rn
+		`)
+
+	var samples []Sample
+	err = db.
+		Table("(?) AS ranked", ranked).
+		Select("id, topic, is_correct").
+		Where("rn <= ?", samplesPerTopic).
+		Order("topic, rn").
+		Scan(&samples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	correct := make(map[string][]uint)
+	incorrect := make(map[string][]uint)
+	for _, s := range samples {
+		if s.IsCorrect {
+			correct[s.Topic] = append(correct[s.Topic], s.ID)
+		} else {
+			incorrect[s.Topic] = append(incorrect[s.Topic], s.ID)
+		}
+	}
+
+	for topic, r := range results {
+		c, w := correct[topic], incorrect[topic]
+		for len(r.SampleAttemptIDs) < samplesPerTopic && (len(c) > 0 || len(w) > 0) {
+			if len(c) > 0 {
+				r.SampleAttemptIDs = append(r.SampleAttemptIDs, c[0])
+				c = c[1:]
+			}
+			if len(w) > 0 && len(r.SampleAttemptIDs) < samplesPerTopic {
+				r.SampleAttemptIDs = append(r.SampleAttemptIDs, w[0])
+				w = w[1:]
+			}
+		}
+		results[topic] = r
+	}
+	return results, nil
+}
+
+// Kinds of DataIssue reported by AuditUserTopicData.
+const (
+	IssueOrphanedAttempt  = "orphaned_attempt"
+	IssueMissingTopic     = "missing_topic"
+	IssueDuplicateAttempt = "duplicate_attempt"
+)
+
+// DataIssue describes one class of problem found in a user's data and
+// the attempts affected by it.
+type DataIssue struct {
+	Kind       string
+	Detail     string
+	AttemptIDs []uint
+}
+
+// AuditUserTopicData checks a user's attempts for problems that silently
+// distort or drop topic stats:
+//
+//   - orphaned attempts whose question no longer exists,
+//   - attempts whose question has no topic,
+//   - duplicate attempts recorded for the same question at the same
+//     instant (the earliest ID is kept as the original).
+//
+// Each kind found is reported once with every affected attempt ID.
+func AuditUserTopicData(db *gorm.DB, userID uuid.UUID) ([]DataIssue, error) {
+	checks := []struct {
+		kind   string
+		detail string
+		query  *gorm.DB
+	}{
+		{
+			kind:   IssueOrphanedAttempt,
+			detail: "attempt references a question that does not exist",
+			query: db.
+				Model(&QuestionAttempt{}).
+				Joins("LEFT JOIN questions ON questions.id = question_attempts.question_id").
+				Where("question_attempts.user_id = ? AND questions.id IS NULL", userID),
+		},
+		{
+			kind:   IssueMissingTopic,
+			detail: "attempt's question has no topic",
+			query: userAttempts(db, userID).
+				Where("questions.topic IS NULL OR questions.topic = ''"),
+		},
+		{
+			kind:   IssueDuplicateAttempt,
+			detail: "attempt duplicates an earlier one for the same question and time",
+			query: db.
+				Model(&QuestionAttempt{}).
+				Joins(`JOIN question_attempts AS original
+					ON original.user_id = question_attempts.user_id
+					AND original.question_id = question_attempts.question_id
+					AND original.created_at = question_attempts.created_at
+					AND original.id < question_attempts.id`).
+				Where("question_attempts.user_id = ?", userID).
+				Distinct(),
+		},
+	}
+
+	var issues []DataIssue
+	for _, check := range checks {
+		var ids []uint
+		if err := check.query.Order("question_attempts.id").Pluck("question_attempts.id", &ids).Error; err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			issues = append(issues, DataIssue{Kind: check.kind, Detail: check.detail, AttemptIDs: ids})
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserTopicAccuracyWithSamples(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := outcomes(user, 1, 0, true, false, true, true, false)
+	for i := range attempts {
+		attempts[i].ID = uint(i + 1)
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateUserTopicAccuracyWithSamples(db, user, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := got["Algebra"]
+	if len(got) != 1 || !approxEqual(sample.Accuracy, 60) {
+		t.Fatalf("got %+v, want Algebra at 60%%", got)
+	}
+	// Newest correct, newest incorrect, next newest correct.
+	if want := []uint{4, 5, 3}; !reflect.DeepEqual(sample.SampleAttemptIDs, want) {
+		t.Fatalf("samples %v, want %v", sample.SampleAttemptIDs, want)
+	}
+}
+
+func TestAuditUserTopicData(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "")
+	attempts := []QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 99, true, 1), // no such question
+		attempt(user, 2, false, 2), // no topic
+		attempt(user, 1, true, 3),
+		attempt(user, 1, true, 3), // duplicate of the one before
+	}
+	for i := range attempts {
+		attempts[i].ID = uint(i + 1)
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := AuditUserTopicData(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]uint{
+		IssueOrphanedAttempt:  {2},
+		IssueMissingTopic:     {3},
+		IssueDuplicateAttempt: {5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want kinds %v", got, want)
+	}
+	for _, issue := range got {
+		if !reflect.DeepEqual(issue.AttemptIDs, want[issue.Kind]) {
+			t.Fatalf("%s: got %v, want %v", issue.Kind, issue.AttemptIDs, want[issue.Kind])
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CohortTopicVariance returns, per topic, the population variance of
+// the listed users' accuracy% in that topic. Users who never attempted
+// a topic are left out of its variance rather than counted as 0.
+func CohortTopicVariance(db *gorm.DB, userIDs []uuid.UUID) (map[string]float64, error) {
+	if len(userIDs) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, userIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	variances := make(map[string]float64)
+	for topic, accs := range accuraciesByTopic(counts) {
+		variances[topic] = variance(accs)
+	}
+	return variances, nil
+}
+
+// CalculateUserAccuracyOnSharedQuestions returns the user's
+// map[topic]accuracy% restricted to questions that the user and every
+// listed peer have all attempted, for like-for-like comparisons.
+func CalculateUserAccuracyOnSharedQuestions(db *gorm.DB, userID uuid.UUID, peers []uuid.UUID) (map[string]float64, error) {
+	group := uniqueUsers(append([]uuid.UUID{userID}, peers...))
+
+	shared := db.
+		Model(&QuestionAttempt{}).
+		Select("question_id").
+		Where("user_id IN ?", group).
+		Group("question_id").
+		Having("COUNT(DISTINCT user_id) = ?", len(group))
+
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.question_id IN (?)", shared),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// uniqueUsers returns ids with duplicates removed, keeping first
+// occurrences in order.
+func uniqueUsers(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// BuildUserTopicMatrix returns a user×topic accuracy% matrix for export:
+// rows are the listed users in order (duplicates dropped), cols every
+// topic any of them attempted, sorted, and values[i][j] is rows[i]'s
+// accuracy in cols[j]. A user who never attempted a topic gets NaN in
+// that cell, so missing data is never mistaken for 0%.
+func BuildUserTopicMatrix(db *gorm.DB, userIDs []uuid.UUID) (rows []uuid.UUID, cols []string, values [][]float64, err error) {
+	rows = uniqueUsers(userIDs)
+	if len(rows) == 0 {
+		return rows, nil, nil, nil
+	}
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, rows))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	colIndex := make(map[string]int)
+	for _, c := range counts {
+		if _, ok := colIndex[c.Topic]; !ok {
+			colIndex[c.Topic] = 0
+			cols = append(cols, c.Topic)
+		}
+	}
+	sort.Strings(cols)
+	for j, topic := range cols {
+		colIndex[topic] = j
+	}
+
+	rowIndex := make(map[uuid.UUID]int, len(rows))
+	values = make([][]float64, len(rows))
+	for i, id := range rows {
+		rowIndex[id] = i
+		values[i] = make([]float64, len(cols))
+		for j := range values[i] {
+			values[i][j] = math.NaN()
+		}
+	}
+	for _, c := range counts {
+		if c.Total > 0 {
+			values[rowIndex[c.UserID]][colIndex[c.Topic]] = c.accuracy()
+		}
+	}
+	return rows, cols, values, nil
+}
+
+// CalculateUserTopicPercentile returns, for each topic the user has
+// attempted, their percentile rank among the cohort's accuracy% in that
+// topic, using percentileRank's mid-rank rule for ties. The user is
+// always ranked with the cohort whether or not they are listed in it;
+// cohort members without attempts in a topic are not ranked there.
+func CalculateUserTopicPercentile(db *gorm.DB, userID uuid.UUID, cohort []uuid.UUID) (map[string]float64, error) {
+	group := uniqueUsers(append([]uuid.UUID{userID}, cohort...))
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, group))
+	if err != nil {
+		return nil, err
+	}
+
+	byTopic := accuraciesByTopic(counts)
+	percentiles := make(map[string]float64)
+	for _, c := range counts {
+		if c.UserID == userID && c.Total > 0 {
+			percentiles[c.Topic] = percentileRank(byTopic[c.Topic], c.accuracy())
+		}
+	}
+	return percentiles, nil
+}
+
+// CompareCohortsTopicAccuracy returns cohortName → topic → accuracy%,
+// pooling the attempts of each cohort's members. All cohorts are served
+// by a single per-user aggregation query and rolled up in Go, so a user
+// listed in several cohorts counts towards each of them.
+func CompareCohortsTopicAccuracy(db *gorm.DB, cohorts map[string][]uuid.UUID) (map[string]map[string]float64, error) {
+	var everyone []uuid.UUID
+	for _, members := range cohorts {
+		everyone = append(everyone, members...)
+	}
+	everyone = uniqueUsers(everyone)
+
+	results := make(map[string]map[string]float64, len(cohorts))
+	if len(everyone) == 0 {
+		for name := range cohorts {
+			results[name] = map[string]float64{}
+		}
+		return results, nil
+	}
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, everyone))
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[uuid.UUID][]topicCount)
+	for _, c := range counts {
+		byUser[c.UserID] = append(byUser[c.UserID], c.topicCount)
+	}
+
+	for name, members := range cohorts {
+		var memberCounts []topicCount
+		for _, id := range uniqueUsers(members) {
+			memberCounts = append(memberCounts, byUser[id]...)
+		}
+		results[name] = accuracyByTopic(poolCounts(memberCounts, func(topic string) string { return topic }))
+	}
+	return results, nil
+}
+
+// CalculateWeightedCohortAccuracy returns a map[topic]accuracy% for the
+// cohort where each user's attempts count with their sampling weight
+// from userWeights (1.0 for users not listed), for survey-style
+// corrections of non-uniform samples. Negative weights are rejected
+// with ErrInvalidWeights.
+func CalculateWeightedCohortAccuracy(db *gorm.DB, userIDs []uuid.UUID, userWeights map[uuid.UUID]float64) (map[string]float64, error) {
+	for _, w := range userWeights {
+		if w < 0 {
+			return nil, ErrInvalidWeights
+		}
+	}
+	userIDs = uniqueUsers(userIDs)
+	if len(userIDs) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, userIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	weighted := make(map[string]float64)
+	correct := make(map[string]float64)
+	for _, c := range counts {
+		w, ok := userWeights[c.UserID]
+		if !ok {
+			w = 1
+		}
+		weighted[c.Topic] += w * float64(c.Total)
+		correct[c.Topic] += w * float64(c.Correct)
+	}
+
+	accuracies := make(map[string]float64, len(weighted))
+	for topic, total := range weighted {
+		if total > 0 {
+			accuracies[topic] = correct[topic] / total * 100
+		}
+	}
+	return accuracies, nil
+}
+
+// CalculateUserVsMedian returns, per topic, the user's accuracy% minus
+// the median accuracy% of the cohort members who attempted that topic.
+// The user only counts towards the median if listed in cohort. Topics
+// the user or the cohort never attempted are omitted.
+func CalculateUserVsMedian(db *gorm.DB, userID uuid.UUID, cohort []uuid.UUID) (map[string]float64, error) {
+	cohort = uniqueUsers(cohort)
+
+	mine, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	if len(cohort) == 0 {
+		return map[string]float64{}, nil
+	}
+	theirs, err := scanUserTopicCounts(cohortAttempts(db, cohort))
+	if err != nil {
+		return nil, err
+	}
+
+	byTopic := accuraciesByTopic(theirs)
+	deltas := make(map[string]float64)
+	for topic, acc := range accuracyByTopic(mine) {
+		if accs := byTopic[topic]; len(accs) > 0 {
+			deltas[topic] = acc - median(accs)
+		}
+	}
+	return deltas, nil
+}
+
+// overallAccuracyByUser pools per-topic counts into each user's overall
+// accuracy%.
+func overallAccuracyByUser(counts []userTopicCount) map[uuid.UUID]float64 {
+	totals := make(map[uuid.UUID]topicCount)
+	for _, c := range counts {
+		t := totals[c.UserID]
+		t.Total += c.Total
+		t.Correct += c.Correct
+		totals[c.UserID] = t
+	}
+
+	accuracies := make(map[uuid.UUID]float64, len(totals))
+	for id, t := range totals {
+		if t.Total > 0 {
+			accuracies[id] = t.accuracy()
+		}
+	}
+	return accuracies
+}
+
+// CalculateUserScaledScore converts the user's overall accuracy into a
+// standardized-test style scaled score between minScale and maxScale:
+//
+//	score = minScale + position * (maxScale - minScale)
+//
+// rounded to the nearest integer, where position is below/(n-1): the
+// share of the other n-1 users in the group (the cohort plus the user)
+// whose overall accuracy is below the user's. The bottom user maps to
+// minScale and the top user to maxScale; each tie counts as half below.
+// Ranking needs at least one other user with attempts, so a user alone
+// scores maxScale.
+// It returns ErrInvalidScale for an empty scale and ErrNoData if the
+// user has no attempts.
+func CalculateUserScaledScore(db *gorm.DB, userID uuid.UUID, cohort []uuid.UUID, minScale, maxScale int) (int, error) {
+	if minScale >= maxScale {
+		return 0, ErrInvalidScale
+	}
+	group := uniqueUsers(append([]uuid.UUID{userID}, cohort...))
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, group))
+	if err != nil {
+		return 0, err
+	}
+	overall := overallAccuracyByUser(counts)
+	mine, ok := overall[userID]
+	if !ok {
+		return 0, ErrNoData
+	}
+	if len(overall) == 1 {
+		return maxScale, nil
+	}
+
+	var below, tied int
+	for id, acc := range overall {
+		switch {
+		case acc < mine:
+			below++
+		case acc == mine && id != userID:
+			tied++
+		}
+	}
+	position := (float64(below) + float64(tied)/2) / float64(len(overall)-1)
+	return minScale + int(math.Round(position*float64(maxScale-minScale))), nil
+}
+
+// CountTopicsWhereUserLeads returns how many topics, and which (sorted),
+// the user has the highest accuracy% in among the cohort. Only members
+// with at least minAttempts attempts in a topic, the user included,
+// compete in it, and a topic counts only if at least one other member
+// qualifies, so the user never leads a topic nobody contests. A tie for
+// first counts as leading, so several users can lead the same topic.
+func CountTopicsWhereUserLeads(db *gorm.DB, userID uuid.UUID, cohort []uuid.UUID, minAttempts int) (int, []string, error) {
+	group := uniqueUsers(append([]uuid.UUID{userID}, cohort...))
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, group))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	mine := make(map[string]float64)
+	best := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, c := range counts {
+		if c.Total == 0 || c.Total < int64(minAttempts) {
+			continue
+		}
+		acc := c.accuracy()
+		if c.UserID == userID {
+			mine[c.Topic] = acc
+		} else if !seen[c.Topic] || acc > best[c.Topic] {
+			best[c.Topic] = acc
+			seen[c.Topic] = true
+		}
+	}
+
+	var led []string
+	for topic, acc := range mine {
+		if seen[topic] && acc >= best[topic] {
+			led = append(led, topic)
+		}
+	}
+	sort.Strings(led)
+	return len(led), led, nil
+}
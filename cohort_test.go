@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCohortTopicVariance(t *testing.T) {
+	db := newTestDB(t)
+	u1, u2, u3 := uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(u1, 1, 0, true))
+	mustCreate(t, db, outcomes(u1, 2, 1, false))
+	mustCreate(t, db, outcomes(u2, 1, 2, true, false))
+
+	got, err := CohortTopicVariance(db, []uuid.UUID{u1, u2, u3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Algebra is {100, 50}; u3 never practised and is left out.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 625, "Geometry": 0})
+}
+
+func TestCalculateUserAccuracyOnSharedQuestions(t *testing.T) {
+	db := newTestDB(t)
+	user, p1, p2 := uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	mustCreate(t, db, outcomes(user, 3, 20, true))
+	mustCreate(t, db, outcomes(p1, 1, 30, true))
+	mustCreate(t, db, outcomes(p1, 2, 31, true))
+	mustCreate(t, db, outcomes(p2, 1, 32, false))
+
+	// Only question 1 was attempted by all three; duplicates are ignored.
+	got, err := CalculateUserAccuracyOnSharedQuestions(db, user, []uuid.UUID{p1, p2, p1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50})
+}
+
+func TestBuildUserTopicMatrix(t *testing.T) {
+	db := newTestDB(t)
+	u1, u2, u3 := uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Geometry", "Algebra")
+	mustCreate(t, db, outcomes(u1, 1, 0, true, false))
+	mustCreate(t, db, outcomes(u2, 2, 10, true))
+
+	rows, cols, values, err := BuildUserTopicMatrix(db, []uuid.UUID{u2, u1, u2, u3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, []uuid.UUID{u2, u1, u3}) {
+		t.Fatalf("rows %v, want u2, u1, u3", rows)
+	}
+	if !reflect.DeepEqual(cols, []string{"Algebra", "Geometry"}) {
+		t.Fatalf("cols %v, want Algebra, Geometry", cols)
+	}
+	nan := math.NaN()
+	want := [][]float64{{100, nan}, {nan, 50}, {nan, nan}}
+	for i := range want {
+		for j := range want[i] {
+			g, w := values[i][j], want[i][j]
+			if math.IsNaN(w) != math.IsNaN(g) || (!math.IsNaN(w) && !approxEqual(g, w)) {
+				t.Fatalf("values %v, want %v", values, want)
+			}
+		}
+	}
+}
+
+func TestCalculateUserTopicPercentile(t *testing.T) {
+	db := newTestDB(t)
+	user, p1, p2, p3 := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 2, true))
+	mustCreate(t, db, outcomes(p1, 1, 10, false))
+	mustCreate(t, db, outcomes(p1, 2, 11, false))
+	mustCreate(t, db, outcomes(p2, 1, 20, true))
+	mustCreate(t, db, outcomes(p3, 1, 30, false, true))
+
+	// The user is ranked even though the cohort leaves them out.
+	got, err := CalculateUserTopicPercentile(db, user, []uuid.UUID{p1, p2, p3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Algebra {0, 50, 50, 100}: one below, two tied. Geometry {0, 100}.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50, "Geometry": 75})
+}
+
+func TestCompareCohortsTopicAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	u1, u2, u3 := uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(u1, 1, 0, true, true, true))
+	mustCreate(t, db, outcomes(u2, 1, 10, false))
+	mustCreate(t, db, outcomes(u3, 2, 20, true))
+
+	// u2 is in both cohorts and counts towards each.
+	got, err := CompareCohortsTopicAccuracy(db, map[string][]uuid.UUID{
+		"morning": {u1, u2},
+		"evening": {u2, u3},
+		"empty":   nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %v, want three cohorts", got)
+	}
+	assertAccuracies(t, got["morning"], map[string]float64{"Algebra": 75})
+	assertAccuracies(t, got["evening"], map[string]float64{"Algebra": 0, "Geometry": 100})
+	assertAccuracies(t, got["empty"], map[string]float64{})
+}
+
+func TestCalculateWeightedCohortAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	u1, u2, u3 := uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(u1, 1, 0, true, false))
+	mustCreate(t, db, outcomes(u2, 1, 10, true, true))
+	mustCreate(t, db, outcomes(u3, 2, 20, true))
+
+	// u2 is unlisted and weighs 1; u3's zero weight leaves Geometry empty.
+	weights := map[uuid.UUID]float64{u1: 3, u3: 0}
+	got, err := CalculateWeightedCohortAccuracy(db, []uuid.UUID{u1, u2, u3}, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 5.0 / 8 * 100})
+
+	if _, err := CalculateWeightedCohortAccuracy(db, []uuid.UUID{u1}, map[uuid.UUID]float64{u1: -1}); !errors.Is(err, ErrInvalidWeights) {
+		t.Fatalf("got %v, want ErrInvalidWeights", err)
+	}
+}
+
+func TestCalculateUserVsMedian(t *testing.T) {
+	db := newTestDB(t)
+	user, p1, p2, p3 := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 4, true))
+	mustCreate(t, db, outcomes(p1, 1, 10, false))
+	mustCreate(t, db, outcomes(p2, 1, 20, true, false))
+	mustCreate(t, db, outcomes(p3, 1, 30, true))
+	mustCreate(t, db, outcomes(p3, 3, 31, true))
+
+	// The user is not in the cohort. Algebra's median is 50; nobody in
+	// the cohort tried Geometry and the user never tried Calculus.
+	got, err := CalculateUserVsMedian(db, user, []uuid.UUID{p1, p2, p3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 25})
+}
+
+func TestCalculateUserScaledScore(t *testing.T) {
+	db := newTestDB(t)
+	top, mid, low, tied := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	mustCreate(t, db, outcomes(top, 1, 0, true))
+	mustCreate(t, db, outcomes(mid, 1, 10, true, false))
+	mustCreate(t, db, outcomes(low, 1, 20, false))
+	mustCreate(t, db, outcomes(tied, 1, 30, false, true))
+
+	cohort := []uuid.UUID{top, mid, low}
+	for user, want := range map[uuid.UUID]int{top: 800, mid: 500, low: 200} {
+		got, err := CalculateUserScaledScore(db, user, cohort, 200, 800)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %d, want %d", got, want)
+		}
+	}
+
+	// Tied with one of three others, one below: (1 + 0.5) / 3.
+	got, err := CalculateUserScaledScore(db, mid, []uuid.UUID{top, low, tied}, 200, 800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 500 {
+		t.Errorf("tied: got %d, want 500", got)
+	}
+
+	got, err = CalculateUserScaledScore(db, low, nil, 200, 800)
+	if err != nil || got != 800 {
+		t.Errorf("alone: got %d, %v; want 800", got, err)
+	}
+
+	if _, err := CalculateUserScaledScore(db, top, cohort, 800, 200); !errors.Is(err, ErrInvalidScale) {
+		t.Fatalf("got %v, want ErrInvalidScale", err)
+	}
+	if _, err := CalculateUserScaledScore(db, uuid.New(), cohort, 200, 800); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestCountTopicsWhereUserLeads(t *testing.T) {
+	db := newTestDB(t)
+	user, peer := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus", "Biology")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true))
+	mustCreate(t, db, outcomes(peer, 1, 2, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true, false))
+	mustCreate(t, db, outcomes(peer, 2, 12, true, false)) // tie
+	mustCreate(t, db, outcomes(user, 3, 20, false, false))
+	mustCreate(t, db, outcomes(peer, 3, 22, true)) // uncontested
+	mustCreate(t, db, outcomes(user, 4, 30, true)) // user too few
+
+	n, topics, err := CountTopicsWhereUserLeads(db, user, []uuid.UUID{peer}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Algebra", "Geometry"}
+	if n != len(want) || !reflect.DeepEqual(topics, want) {
+		t.Fatalf("got %d %v, want %v", n, topics, want)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TopicDataNeed reports how many more attempts a topic needs before
+// its stats are shown.
+type TopicDataNeed struct {
+	Topic string
+	Need  int
+}
+
+// ListTopicsNeedingMoreData returns every topic in the question bank
+// where the user has fewer than minAttempts attempts, including topics
+// they have never tried, ordered by how close each is to unlocking.
+func ListTopicsNeedingMoreData(db *gorm.DB, userID uuid.UUID, minAttempts int) ([]TopicDataNeed, error) {
+	var topics []string
+	if err := db.Model(&Question{}).Distinct("topic").Pluck("topic", &topics).Error; err != nil {
+		return nil, err
+	}
+
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	attempted := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		attempted[c.Topic] = c.Total
+	}
+
+	var needs []TopicDataNeed
+	for _, topic := range topics {
+		if have := int(attempted[topic]); have < minAttempts {
+			needs = append(needs, TopicDataNeed{Topic: topic, Need: minAttempts - have})
+		}
+	}
+	sort.Slice(needs, func(i, j int) bool {
+		if needs[i].Need != needs[j].Need {
+			return needs[i].Need < needs[j].Need
+		}
+		return needs[i].Topic < needs[j].Topic
+	})
+	return needs, nil
+}
+
+// CalculateUserTopicCoverage returns a map[topic]coverage%: the share of
+// each topic's questions the user has attempted at least once. Topics
+// the user has not touched report 0.
+func CalculateUserTopicCoverage(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	type Result struct {
+		Topic     string
+		Questions int64
+		Attempted int64
+	}
+
+	attempted := db.
+		Model(&QuestionAttempt{}).
+		Distinct("question_id").
+		Where("user_id = ?", userID)
+
+	var results []Result
+	err := db.
+		Model(&Question{}).
+		Select(`
+			questions.topic               AS topic,
+			COUNT(*)                      AS questions,
+			COUNT(attempted.question_id)  AS attempted
+		`).
+		Joins("LEFT JOIN (?) AS attempted ON attempted.question_id = questions.id", attempted).
+		Group("questions.topic").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := make(map[string]float64, len(results))
+	for _, r := range results {
+		if r.Questions > 0 {
+			coverage[r.Topic] = float64(r.Attempted) * 100 / float64(r.Questions)
+		}
+	}
+	return coverage, nil
+}
+
+// CalculateUserRetryHistogram returns a map[attemptCount]questions: how
+// many of the questions the user tried were attempted once, twice, and
+// so on.
+func CalculateUserRetryHistogram(db *gorm.DB, userID uuid.UUID) (map[int]int, error) {
+	type Result struct {
+		Attempts  int
+		Questions int
+	}
+
+	perQuestion := db.
+		Model(&QuestionAttempt{}).
+		Select("question_id, COUNT(*) AS attempts").
+		Where("user_id = ?", userID).
+		Group("question_id")
+
+	var results []Result
+	err := db.
+		Table("(?) AS per_question", perQuestion).
+		Select("attempts, COUNT(*) AS questions").
+		Group("attempts").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	histogram := make(map[int]int, len(results))
+	for _, r := range results {
+		histogram[r.Attempts] = r.Questions
+	}
+	return histogram, nil
+}
+
+// CalculateUserCorrectCount returns the user's lifetime number of
+// correct answers. With distinctQuestions set it counts each question
+// answered correctly at least once a single time, so re-answering an
+// already-solved question does not advance the counter.
+func CalculateUserCorrectCount(db *gorm.DB, userID uuid.UUID, distinctQuestions bool) (int64, error) {
+	q := db.
+		Model(&QuestionAttempt{}).
+		Where("user_id = ? AND is_correct", userID)
+	if distinctQuestions {
+		q = q.Distinct("question_id")
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CalculateUserCollectionAccuracy returns a map[collection]accuracy%
+// pooling the user's attempts across each collection's topics (e.g.
+// "Midterm Scope" → {"Algebra", "Geometry"}). A topic listed in several
+// collections counts fully towards each; collections whose topics the
+// user never attempted are omitted.
+func CalculateUserCollectionAccuracy(db *gorm.DB, userID uuid.UUID, collections map[string][]string) (map[string]float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	byTopic := make(map[string]topicCount, len(counts))
+	for _, c := range counts {
+		byTopic[c.Topic] = c
+	}
+
+	var pooled []topicCount
+	for name, topics := range collections {
+		seen := make(map[string]bool, len(topics))
+		for _, topic := range topics {
+			if c, ok := byTopic[topic]; ok && !seen[topic] {
+				seen[topic] = true
+				c.Topic = name
+				pooled = append(pooled, c)
+			}
+		}
+	}
+	return accuracyByTopic(poolCounts(pooled, func(name string) string { return name })), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestListTopicsNeedingMoreData(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Calculus", "Geometry", "Biology")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	mustCreate(t, db, outcomes(user, 3, 20, false, false))
+
+	got, err := ListTopicsNeedingMoreData(db, user, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TopicDataNeed{
+		{Topic: "Geometry", Need: 1},
+		{Topic: "Calculus", Need: 2},
+		{Topic: "Biology", Need: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateUserRetryHistogram(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Geometry", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, false, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	mustCreate(t, db, outcomes(user, 3, 20, false, false, true))
+	mustCreate(t, db, outcomes(user, 4, 30, true))
+	mustCreate(t, db, outcomes(uuid.New(), 1, 40, true))
+
+	got, err := CalculateUserRetryHistogram(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]int{1: 2, 2: 1, 3: 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateUserCorrectCount(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Algebra")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true))
+	mustCreate(t, db, outcomes(user, 2, 10, false, true))
+	mustCreate(t, db, outcomes(user, 3, 20, false))
+
+	for distinct, want := range map[bool]int64{false: 4, true: 2} {
+		got, err := CalculateUserCorrectCount(db, user, distinct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("distinctQuestions=%v: got %d, want %d", distinct, got, want)
+		}
+	}
+}
+
+func TestCalculateUserCollectionAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true, true))
+	mustCreate(t, db, outcomes(user, 3, 20, false))
+
+	// Algebra counts fully towards both collections; a repeated topic
+	// counts once; a collection never attempted is omitted.
+	got, err := CalculateUserCollectionAccuracy(db, user, map[string][]string{
+		"Midterm": {"Algebra", "Geometry", "Algebra"},
+		"Final":   {"Algebra", "Calculus"},
+		"Bonus":   {"Biology"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Midterm": 75, "Final": 100.0 / 3})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DashboardData bundles everything the student dashboard shows.
+type DashboardData struct {
+	// Topics is the user's per-topic stats, sorted by topic.
+	Topics []TopicStats
+	// OverallAccuracy pools all of the user's attempts, as
+	// CalculateUserOverallAccuracyStrategy does with StrategyMicro.
+	OverallAccuracy float64
+	// CohortAccuracy is the cohort's pooled accuracy% per topic, as
+	// CompareCohortsTopicAccuracy reports it for this cohort. The user
+	// only contributes if listed in cohort.
+	CohortAccuracy map[string]float64
+}
+
+// CalculateDashboardData computes the user's per-topic stats, overall
+// accuracy and the cohort comparison in one database round-trip: a
+// single per-user, per-topic aggregation over the user and the cohort,
+// from which all three are derived in Go.
+func CalculateDashboardData(db *gorm.DB, userID uuid.UUID, cohort []uuid.UUID) (*DashboardData, error) {
+	cohort = uniqueUsers(cohort)
+	group := uniqueUsers(append([]uuid.UUID{userID}, cohort...))
+
+	counts, err := scanUserTopicCounts(cohortAttempts(db, group))
+	if err != nil {
+		return nil, err
+	}
+
+	inCohort := make(map[uuid.UUID]bool, len(cohort))
+	for _, id := range cohort {
+		inCohort[id] = true
+	}
+
+	data := &DashboardData{}
+	var overall topicCount
+	var cohortCounts []topicCount
+	for _, c := range counts {
+		if c.UserID == userID && c.Total > 0 {
+			data.Topics = append(data.Topics, TopicStats{
+				Topic:    c.Topic,
+				Total:    c.Total,
+				Correct:  c.Correct,
+				Accuracy: c.accuracy(),
+			})
+			overall.Total += c.Total
+			overall.Correct += c.Correct
+		}
+		if inCohort[c.UserID] {
+			cohortCounts = append(cohortCounts, c.topicCount)
+		}
+	}
+	sort.Slice(data.Topics, func(i, j int) bool { return data.Topics[i].Topic < data.Topics[j].Topic })
+	data.OverallAccuracy = overall.accuracy()
+	data.CohortAccuracy = accuracyByTopic(poolCounts(cohortCounts, func(topic string) string { return topic }))
+	return data, nil
+}
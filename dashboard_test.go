@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// countQueries registers callbacks on db that count every statement it
+// runs through Find, Scan, Pluck, Row and Raw, and returns the counter.
+func countQueries(t *testing.T, db *gorm.DB) *int {
+	t.Helper()
+	var n int
+	count := func(*gorm.DB) { n++ }
+	cb := db.Callback()
+	for name, err := range map[string]error{
+		"query": cb.Query().After("gorm:query").Register("test:count_query", count),
+		"row":   cb.Row().After("gorm:row").Register("test:count_row", count),
+		"raw":   cb.Raw().After("gorm:raw").Register("test:count_raw", count),
+	} {
+		if err != nil {
+			t.Fatalf("register %s callback: %v", name, err)
+		}
+	}
+	return &n
+}
+
+func TestCalculateDashboardData(t *testing.T) {
+	db := newTestDB(t)
+	user, p1, p2 := uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Geometry", "Algebra", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	mustCreate(t, db, outcomes(p1, 2, 20, false, true))
+	mustCreate(t, db, outcomes(p2, 3, 30, true))
+	cohort := []uuid.UUID{p1, p2, p1}
+
+	queries := countQueries(t, db)
+	got, err := CalculateDashboardData(db, user, cohort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *queries != 1 {
+		t.Fatalf("ran %d queries, want 1", *queries)
+	}
+
+	want := []TopicStats{
+		{Topic: "Algebra", Total: 1, Correct: 1, Accuracy: 100},
+		{Topic: "Geometry", Total: 3, Correct: 2, Accuracy: 200.0 / 3},
+	}
+	if len(got.Topics) != len(want) {
+		t.Fatalf("topics %+v, want %+v", got.Topics, want)
+	}
+	for i, w := range want {
+		g := got.Topics[i]
+		if g.Topic != w.Topic || g.Total != w.Total || g.Correct != w.Correct || !approxEqual(g.Accuracy, w.Accuracy) {
+			t.Fatalf("topics %+v, want %+v", got.Topics, want)
+		}
+	}
+
+	overall, err := CalculateUserOverallAccuracyStrategy(db, user, StrategyMicro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approxEqual(got.OverallAccuracy, overall) {
+		t.Fatalf("overall %v, want %v", got.OverallAccuracy, overall)
+	}
+
+	cohorts, err := CompareCohortsTopicAccuracy(db, map[string][]uuid.UUID{"cohort": cohort})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got.CohortAccuracy, cohorts["cohort"])
+	assertAccuracies(t, got.CohortAccuracy, map[string]float64{"Algebra": 50, "Calculus": 100})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// communityRates selects each question's correct rate (0–1) across all
+// users as (question_id, rate).
+func communityRates(db *gorm.DB) *gorm.DB {
+	return db.
+		Model(&QuestionAttempt{}).
+		Select(`
+			question_id,
+			AVG(CASE WHEN is_correct THEN 1.0 ELSE 0.0 END) AS rate
+		`).
+		Group("question_id")
+}
+
+// CalculateUserAccuracyOnHardQuestions returns the user's
+// map[topic]accuracy% over questions whose community correct rate, the
+// share of all users' attempts answered correctly, is below
+// maxCommunityRate (0–1).
+func CalculateUserAccuracyOnHardQuestions(db *gorm.DB, userID uuid.UUID, maxCommunityRate float64) (map[string]float64, error) {
+	if maxCommunityRate < 0 || maxCommunityRate > 1 {
+		return nil, ErrInvalidThreshold
+	}
+
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).
+			Joins("JOIN (?) AS community ON community.question_id = question_attempts.question_id", communityRates(db)).
+			Where("community.rate < ?", maxCommunityRate),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// AnalyzeDistractors returns, for a multiple-choice question, how many
+// distinct users chose each option. Options are labelled "A", "B", …
+// up to the question's OptionCount and options nobody picked are
+// reported as 0, so unattractive distractors show up too. Attempts
+// without a ChosenOption are ignored.
+func AnalyzeDistractors(db *gorm.DB, questionID uint) (map[string]int, error) {
+	var question Question
+	if err := db.First(&question, questionID).Error; err != nil {
+		return nil, err
+	}
+
+	type Result struct {
+		ChosenOption string
+		Users        int
+	}
+
+	var results []Result
+	err := db.
+		Model(&QuestionAttempt{}).
+		Select("chosen_option, COUNT(DISTINCT user_id) AS users").
+		Where("question_id = ? AND chosen_option <> ''", questionID).
+		Group("chosen_option").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	choices := make(map[string]int, question.OptionCount)
+	for i := 0; i < question.OptionCount && i < 26; i++ {
+		choices[string(rune('A'+i))] = 0
+	}
+	for _, r := range results {
+		choices[r.ChosenOption] = r.Users
+	}
+	return choices, nil
+}
+
+// UserAccuracy is one user's leaderboard entry.
+type UserAccuracy struct {
+	UserID   uuid.UUID
+	Attempts int64
+	Accuracy float64
+	// AdjustedAccuracy is how many percentage points the user beat the
+	// community correct rate of the questions they attempted by.
+	AdjustedAccuracy float64
+}
+
+// AdjustedLeaderboard ranks every user with at least minAttempts
+// attempts by AdjustedAccuracy, best first: their share of correct
+// answers minus the share they would be expected to get right if they
+// performed like the community on each question they attempted. A user
+// tackling hard questions is not penalised for a lower raw accuracy.
+// Ties are broken by raw accuracy.
+func AdjustedLeaderboard(db *gorm.DB, minAttempts int) ([]UserAccuracy, error) {
+	type Result struct {
+		UserID   uuid.UUID
+		Total    int64
+		Correct  int64
+		Expected float64
+	}
+
+	var results []Result
+	err := db.
+		Model(&QuestionAttempt{}).
+		Select(`
+			question_attempts.user_id                                      AS user_id,
+			COUNT(*)                                                       AS total,
+			SUM(CASE WHEN question_attempts.is_correct THEN 1 ELSE 0 END)  AS correct,
+			SUM(community.rate)                                            AS expected
+		`).
+		Joins("JOIN (?) AS community ON community.question_id = question_attempts.question_id", communityRates(db)).
+		Group("question_attempts.user_id").
+		Having("COUNT(*) >= ?", minAttempts).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	board := make([]UserAccuracy, 0, len(results))
+	for _, r := range results {
+		if r.Total == 0 {
+			continue
+		}
+		board = append(board, UserAccuracy{
+			UserID:           r.UserID,
+			Attempts:         r.Total,
+			Accuracy:         topicCount{Total: r.Total, Correct: r.Correct}.accuracy(),
+			AdjustedAccuracy: (float64(r.Correct) - r.Expected) * 100 / float64(r.Total),
+		})
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].AdjustedAccuracy != board[j].AdjustedAccuracy {
+			return board[i].AdjustedAccuracy > board[j].AdjustedAccuracy
+		}
+		return board[i].Accuracy > board[j].Accuracy
+	})
+	return board, nil
+}
+
+// CalculateUserStratifiedAccuracy returns the user's overall accuracy%
+// as if their attempts followed the target difficulty mix instead of
+// their actual one: accuracy is computed per Difficulty and combined
+// using difficultyWeights (normalized to sum to 1). Difficulty levels
+// the user has not attempted are dropped and the remaining weights
+// renormalized. It returns ErrNoData if the user has attempted none of
+// the weighted levels.
+func CalculateUserStratifiedAccuracy(db *gorm.DB, userID uuid.UUID, difficultyWeights map[string]float64) (float64, error) {
+	if _, err := normalizeWeights(difficultyWeights); err != nil {
+		return 0, err
+	}
+
+	counts, err := scanCountsBy(userAttempts(db, userID), "questions.difficulty")
+	if err != nil {
+		return 0, err
+	}
+	return weightedAverage(accuracyByTopic(counts), difficultyWeights)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserAccuracyOnHardQuestions(t *testing.T) {
+	db := newTestDB(t)
+	user, peer := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra")
+	// Question 1 is answered correctly 1 time in 3, question 2 2 in 3.
+	mustCreate(t, db, outcomes(user, 1, 0, true))
+	mustCreate(t, db, outcomes(peer, 1, 1, false, false))
+	mustCreate(t, db, outcomes(user, 2, 10, false))
+	mustCreate(t, db, outcomes(peer, 2, 11, true, true))
+
+	got, err := CalculateUserAccuracyOnHardQuestions(db, user, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 100})
+
+	if _, err := CalculateUserAccuracyOnHardQuestions(db, user, 2); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+}
+
+func TestAnalyzeDistractors(t *testing.T) {
+	db := newTestDB(t)
+	u1, u2, u3 := uuid.New(), uuid.New(), uuid.New()
+	mustCreate(t, db, &Question{ID: 1, Topic: "Algebra", OptionCount: 4})
+	attempts := []QuestionAttempt{
+		attempt(u1, 1, false, 0),
+		attempt(u1, 1, false, 1),
+		attempt(u2, 1, false, 2),
+		attempt(u3, 1, true, 3),
+		attempt(u3, 1, true, 4),
+	}
+	for i, option := range []string{"B", "B", "B", "C", ""} {
+		attempts[i].ChosenOption = option
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := AnalyzeDistractors(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Users are counted once per option; unpicked options report 0.
+	if want := map[string]int{"A": 0, "B": 2, "C": 1, "D": 0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestAdjustedLeaderboard(t *testing.T) {
+	db := newTestDB(t)
+	hard, easy, weak, strong := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra")
+	// Question 1 is right 2 times in 8, question 2 7 times in 8.
+	mustCreate(t, db, outcomes(hard, 1, 0, true, true, false, false))
+	mustCreate(t, db, outcomes(weak, 1, 10, false, false, false, false))
+	mustCreate(t, db, outcomes(easy, 2, 20, true, true, true, false))
+	mustCreate(t, db, outcomes(strong, 2, 30, true, true, true, true))
+
+	got, err := AdjustedLeaderboard(db, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hard's 50% on the hard question outranks easy's 75% on the easy one.
+	want := []UserAccuracy{
+		{UserID: hard, Attempts: 4, Accuracy: 50, AdjustedAccuracy: (2 - 4*0.25) * 100 / 4},
+		{UserID: strong, Attempts: 4, Accuracy: 100, AdjustedAccuracy: (4 - 4*0.875) * 100 / 4},
+		{UserID: easy, Attempts: 4, Accuracy: 75, AdjustedAccuracy: (3 - 4*0.875) * 100 / 4},
+		{UserID: weak, Attempts: 4, Accuracy: 0, AdjustedAccuracy: (0 - 4*0.25) * 100 / 4},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.UserID != w.UserID || g.Attempts != w.Attempts || !approxEqual(g.Accuracy, w.Accuracy) || !approxEqual(g.AdjustedAccuracy, w.AdjustedAccuracy) {
+			t.Fatalf("entry %d: got %+v, want %+v", i, g, w)
+		}
+	}
+
+	got, err = AdjustedLeaderboard(db, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %+v, want no users with 5 attempts", got)
+	}
+}
+
+func TestCalculateUserStratifiedAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	mustCreate(t, db, &[]Question{
+		{ID: 1, Topic: "Algebra", Difficulty: "easy"},
+		{ID: 2, Topic: "Algebra", Difficulty: "hard"},
+	})
+	mustCreate(t, db, outcomes(user, 1, 0, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true, false, false, false))
+
+	// "medium" was never attempted, so easy and hard renormalize to 1:3.
+	got, err := CalculateUserStratifiedAccuracy(db, user, map[string]float64{"easy": 1, "medium": 1, "hard": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (100*1 + 25*3) / 4.0; !approxEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if _, err := CalculateUserStratifiedAccuracy(db, user, map[string]float64{"medium": 1}); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// TopicStats is one topic's aggregated result, as cached.
+type TopicStats struct {
+	Topic    string
+	Total    int64
+	Correct  int64
+	Accuracy float64
+}
+
+// summaryVersion is the first byte of every encoded summary.
+const summaryVersion = 1
+
+// MarshalAccuracySummary encodes stats in a compact binary form for
+// caching. The layout is a version byte, the entry count as a uvarint,
+// and then for each entry the topic as a uvarint length followed by its
+// UTF-8 bytes, Total and Correct as varints, and Accuracy as 8
+// little-endian bytes of its IEEE 754 bits.
+func MarshalAccuracySummary(stats []TopicStats) ([]byte, error) {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(stats)*24)
+	buf = append(buf, summaryVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(stats)))
+	for _, s := range stats {
+		buf = binary.AppendUvarint(buf, uint64(len(s.Topic)))
+		buf = append(buf, s.Topic...)
+		buf = binary.AppendVarint(buf, s.Total)
+		buf = binary.AppendVarint(buf, s.Correct)
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(s.Accuracy))
+	}
+	return buf, nil
+}
+
+// UnmarshalAccuracySummary decodes data produced by
+// MarshalAccuracySummary. It returns ErrInvalidSummary if data is
+// truncated, has trailing bytes, or has an unknown version.
+func UnmarshalAccuracySummary(data []byte) ([]TopicStats, error) {
+	if len(data) == 0 || data[0] != summaryVersion {
+		return nil, ErrInvalidSummary
+	}
+	data = data[1:]
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return nil, ErrInvalidSummary
+	}
+	data = data[n:]
+
+	stats := make([]TopicStats, 0, count)
+	for i := uint64(0); i < count; i++ {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return nil, ErrInvalidSummary
+		}
+		data = data[n:]
+		s := TopicStats{Topic: string(data[:size])}
+		data = data[size:]
+
+		if s.Total, n = binary.Varint(data); n <= 0 {
+			return nil, ErrInvalidSummary
+		}
+		data = data[n:]
+		if s.Correct, n = binary.Varint(data); n <= 0 {
+			return nil, ErrInvalidSummary
+		}
+		data = data[n:]
+
+		if len(data) < 8 {
+			return nil, ErrInvalidSummary
+		}
+		s.Accuracy = math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+
+		stats = append(stats, s)
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidSummary
+	}
+	return stats, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAccuracySummaryRoundTrip(t *testing.T) {
+	stats := []TopicStats{
+		{Topic: "Algebra", Total: 4, Correct: 3, Accuracy: 75},
+		{Topic: "Géométrie", Total: 300, Correct: 1, Accuracy: 1.0 / 3},
+		{Topic: "", Total: 0, Correct: 0, Accuracy: 0},
+	}
+	data, err := MarshalAccuracySummary(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalAccuracySummary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, stats) {
+		t.Fatalf("got %+v, want %+v", got, stats)
+	}
+
+	for name, bad := range map[string][]byte{
+		"empty":     nil,
+		"version":   append([]byte{2}, data[1:]...),
+		"truncated": data[:len(data)-1],
+		"trailing":  append(append([]byte(nil), data...), 0),
+	} {
+		if _, err := UnmarshalAccuracySummary(bad); !errors.Is(err, ErrInvalidSummary) {
+			t.Errorf("%s: got %v, want ErrInvalidSummary", name, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import "errors"
+
+var (
+	// ErrNoData is returned when there are no attempts to base a
+	// calculation on.
+	ErrNoData = errors.New("no attempts found")
+
+	// ErrNoQualifyingTopic is returned when no topic meets the minimum
+	// attempt count a calculation requires.
+	ErrNoQualifyingTopic = errors.New("no topic has enough attempts")
+
+	// ErrInvalidWeights is returned when blend weights are negative or
+	// all zero.
+	ErrInvalidWeights = errors.New("weights must be non-negative and not all zero")
+
+	// ErrInvalidHourRange is returned when an hour window is not
+	// 0 <= start < end <= 24.
+	ErrInvalidHourRange = errors.New("hour range must satisfy 0 <= start < end <= 24")
+
+	// ErrUnreachable is returned when a goal's deadline has already
+	// passed.
+	ErrUnreachable = errors.New("goal deadline has passed")
+
+	// ErrInvalidThreshold is returned when a score threshold lies
+	// outside [0, 1].
+	ErrInvalidThreshold = errors.New("threshold must be between 0 and 1")
+
+	// ErrInvalidWarmup is returned for a negative warmup count.
+	ErrInvalidWarmup = errors.New("warmup must not be negative")
+
+	// ErrInvalidCurve is returned for an unknown CurveKind.
+	ErrInvalidCurve = errors.New("unknown curve kind")
+
+	// ErrInvalidPoints is returned when fewer than one point is
+	// requested for a series.
+	ErrInvalidPoints = errors.New("points must be at least 1")
+
+	// ErrInvalidBucket is returned for a time bucket other than "day",
+	// "week" or "month".
+	ErrInvalidBucket = errors.New(`bucket must be "day", "week" or "month"`)
+
+	// ErrInvalidDuration is returned when a cutoff or half-life is not
+	// positive.
+	ErrInvalidDuration = errors.New("duration must be positive")
+
+	// ErrSnapshotNotFound is returned when no stored snapshot exists on
+	// or before the requested time.
+	ErrSnapshotNotFound = errors.New("no snapshot found")
+
+	// ErrInvalidWindow is returned when a smoothing or sliding window is
+	// smaller than 1.
+	ErrInvalidWindow = errors.New("window must be at least 1")
+
+	// ErrInsufficientData is returned when there are attempts, but too
+	// few for a meaningful result.
+	ErrInsufficientData = errors.New("not enough attempts")
+
+	// ErrInvalidBands is returned when band edges are empty or not
+	// strictly increasing.
+	ErrInvalidBands = errors.New("bands must be a non-empty, strictly increasing list")
+
+	// ErrInvalidStrategy is returned for an unknown aggregation
+	// strategy.
+	ErrInvalidStrategy = errors.New(`strategy must be "micro" or "macro"`)
+
+	// ErrInvalidScale is returned when a score scale's minimum is not
+	// below its maximum.
+	ErrInvalidScale = errors.New("scale minimum must be below maximum")
+
+	// ErrInvalidSummary is returned when an encoded accuracy summary is
+	// truncated, malformed, or of an unknown version.
+	ErrInvalidSummary = errors.New("invalid encoded accuracy summary")
+
+	// ErrNotMastered is returned when the user's running accuracy never
+	// reached the mastery threshold.
+	ErrNotMastered = errors.New("topic never mastered")
+
+	// ErrInvalidConfidence is returned when a confidence band's z-score
+	// is not positive.
+	ErrInvalidConfidence = errors.New("z-score must be positive")
+
+	// ErrInvalidTimeRange is returned when a time range ends before it
+	// starts.
+	ErrInvalidTimeRange = errors.New("time range ends before it starts")
+)
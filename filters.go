@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalculateUserTopicAccuracyForGrade returns a map[topic]accuracy%
+// counting only attempts at questions written for the given grade.
+func CalculateUserTopicAccuracyForGrade(db *gorm.DB, userID uuid.UUID, grade int) (map[string]float64, error) {
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).Where("questions.grade_level = ?", grade),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserAccuracyByType returns a map[questionType]accuracy%.
+// Questions without a type are grouped under "unknown".
+func CalculateUserAccuracyByType(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	counts, err := scanCountsBy(
+		userAttempts(db, userID),
+		"COALESCE(NULLIF(questions.question_type, ''), 'unknown')",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserTopicAccuracyBusinessHours returns a map[topic]accuracy%
+// counting only attempts whose local hour in loc falls within
+// [startHour, endHour). A nil loc means UTC. The hour is derived in Go
+// because not every database can convert time zones.
+func CalculateUserTopicAccuracyBusinessHours(db *gorm.DB, userID uuid.UUID, startHour, endHour int, loc *time.Location) (map[string]float64, error) {
+	if startHour < 0 || endHour > 24 || startHour >= endHour {
+		return nil, ErrInvalidHourRange
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	var inHours []attemptRecord
+	for _, r := range records {
+		if h := r.CreatedAt.In(loc).Hour(); h >= startHour && h < endHour {
+			inHours = append(inHours, r)
+		}
+	}
+	return accuracyByTopic(countByTopic(inHours)), nil
+}
+
+// CalculateUserAccuracyGrouped returns a map[key]accuracy% where each
+// attempt's key comes from keyFn, for groupings SQL cannot express.
+//
+// Unlike the other calculations this loads every one of the user's
+// attempts, with its question, into memory; prefer a SQL variant for
+// users with very large histories.
+func CalculateUserAccuracyGrouped(db *gorm.DB, userID uuid.UUID, keyFn func(QuestionAttempt, Question) string) (map[string]float64, error) {
+	var attempts []QuestionAttempt
+	if err := db.Preload("Question").Where("user_id = ?", userID).Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]attemptRecord, len(attempts))
+	for i, a := range attempts {
+		records[i] = attemptRecord{Topic: keyFn(a, a.Question), IsCorrect: a.IsCorrect}
+	}
+	return accuracyByTopic(countByTopic(records)), nil
+}
+
+// CalculateUserTopicAccuracyScoreThreshold returns a map[topic]accuracy%
+// that ignores IsCorrect and instead treats an attempt as correct when
+// its Score is at least threshold (0–1).
+func CalculateUserTopicAccuracyScoreThreshold(db *gorm.DB, userID uuid.UUID, threshold float64) (map[string]float64, error) {
+	if threshold < 0 || threshold > 1 {
+		return nil, ErrInvalidThreshold
+	}
+
+	var counts []topicCount
+	err := userAttempts(db, userID).
+		Select(`
+			questions.topic                                                 AS topic,
+			COUNT(*)                                                        AS total,
+			SUM(CASE WHEN question_attempts.score >= ? THEN 1 ELSE 0 END)   AS correct
+		`, threshold).
+		Group("questions.topic").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserAccuracyByTimed returns the user's accuracy% on timed
+// (true) and untimed (false) attempts. A key is present only if the
+// user has attempts of that kind.
+func CalculateUserAccuracyByTimed(db *gorm.DB, userID uuid.UUID) (map[bool]float64, error) {
+	type Result struct {
+		Timed   bool
+		Total   int64
+		Correct int64
+	}
+
+	var results []Result
+	err := db.
+		Model(&QuestionAttempt{}).
+		Select(`
+			timed,
+			COUNT(*)                                     AS total,
+			SUM(CASE WHEN is_correct THEN 1 ELSE 0 END)  AS correct
+		`).
+		Where("user_id = ?", userID).
+		Group("timed").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	accuracies := make(map[bool]float64, len(results))
+	for _, r := range results {
+		accuracies[r.Timed] = topicCount{Total: r.Total, Correct: r.Correct}.accuracy()
+	}
+	return accuracies, nil
+}
+
+// CalculateTopicAccuracyForBatch returns a map[topic]accuracy% over the
+// attempts of all users that arrived in the given ingestion batch.
+func CalculateTopicAccuracyForBatch(db *gorm.DB, batchID string) (map[string]float64, error) {
+	counts, err := scanTopicCounts(
+		db.
+			Model(&QuestionAttempt{}).
+			Joins("JOIN questions ON questions.id = question_attempts.question_id").
+			Where("question_attempts.batch_id = ?", batchID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserTopicAccuracyVariableThreshold returns a
+// map[topic]accuracy% omitting topics with fewer attempts than their
+// entry in thresholds, or defaultMinAttempts for unlisted topics, so
+// core topics can demand more evidence than peripheral ones.
+func CalculateUserTopicAccuracyVariableThreshold(db *gorm.DB, userID uuid.UUID, thresholds map[string]int, defaultMinAttempts int) (map[string]float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	var qualifying []topicCount
+	for _, c := range counts {
+		required, ok := thresholds[c.Topic]
+		if !ok {
+			required = defaultMinAttempts
+		}
+		if c.Total >= int64(required) {
+			qualifying = append(qualifying, c)
+		}
+	}
+	return accuracyByTopic(qualifying), nil
+}
+
+// CalculateUserBookmarkedAccuracy returns a map[topic]accuracy% over
+// questions the user has marked for review on any of their attempts.
+// Every attempt at such a question counts, including those made before
+// it was marked.
+func CalculateUserBookmarkedAccuracy(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	marked := db.
+		Model(&QuestionAttempt{}).
+		Select("question_id").
+		Where("user_id = ? AND marked_for_review", userID)
+
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.question_id IN (?)", marked),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserAccuracyByComplexityBand returns a map[band]accuracy%
+// with questions bucketed by Complexity. bands are the edges between
+// buckets and each band includes its lower edge but not its upper one:
+// edges [3, 6] give the bands "<3", "[3,6)" and ">=6".
+func CalculateUserAccuracyByComplexityBand(db *gorm.DB, userID uuid.UUID, bands []int) (map[string]float64, error) {
+	if err := validateBands(bands); err != nil {
+		return nil, err
+	}
+
+	type Result struct {
+		Complexity int
+		Total      int64
+		Correct    int64
+	}
+
+	var results []Result
+	err := userAttempts(db, userID).
+		Select(`
+			questions.complexity                                           AS complexity,
+			COUNT(*)                                                       AS total,
+			SUM(CASE WHEN question_attempts.is_correct THEN 1 ELSE 0 END)  AS correct
+		`).
+		Group("questions.complexity").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]topicCount, len(results))
+	for i, r := range results {
+		counts[i] = topicCount{Topic: complexityBand(bands, r.Complexity), Total: r.Total, Correct: r.Correct}
+	}
+	return accuracyByTopic(poolCounts(counts, func(band string) string { return band })), nil
+}
+
+// validateBands checks that band edges are non-empty and strictly
+// increasing.
+func validateBands(bands []int) error {
+	if len(bands) == 0 {
+		return ErrInvalidBands
+	}
+	for i := 1; i < len(bands); i++ {
+		if bands[i] <= bands[i-1] {
+			return ErrInvalidBands
+		}
+	}
+	return nil
+}
+
+// complexityBand labels the band of bands that complexity falls in.
+func complexityBand(bands []int, complexity int) string {
+	return bandLabel(bands, sort.SearchInts(bands, complexity+1), "")
+}
+
+// bandLabel names band i of edges, writing unit after each edge. Bands
+// include their lower edge but not their upper one and are labelled as
+// such: edges [3, 6] give "<3", "[3,6)" and ">=6".
+func bandLabel(edges []int, i int, unit string) string {
+	switch {
+	case i == 0:
+		return fmt.Sprintf("<%d%s", edges[0], unit)
+	case i == len(edges):
+		return fmt.Sprintf(">=%d%s", edges[i-1], unit)
+	}
+	return fmt.Sprintf("[%d%s,%d%s)", edges[i-1], unit, edges[i], unit)
+}
+
+// CalculateUserTopicAccuracyWithAliases returns a map[topic]accuracy%
+// after renaming topics through aliases (source → canonical name), so
+// that e.g. "Trig" and "Trigonometry" pool their attempts under one
+// entry. Topics without an alias keep their own name.
+func CalculateUserTopicAccuracyWithAliases(db *gorm.DB, userID uuid.UUID, aliases map[string]string) (map[string]float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	canonical := func(topic string) string {
+		if name, ok := aliases[topic]; ok {
+			return name
+		}
+		return topic
+	}
+	return accuracyByTopic(poolCounts(counts, canonical)), nil
+}
+
+// CalculateUserTopicAccuracyMinDistinctQuestions returns a
+// map[topic]accuracy% omitting topics where the user attempted fewer
+// than minDistinctQuestions different questions. Unlike a minimum
+// attempt count, retrying the same question does not help a topic
+// qualify.
+func CalculateUserTopicAccuracyMinDistinctQuestions(db *gorm.DB, userID uuid.UUID, minDistinctQuestions int) (map[string]float64, error) {
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).
+			Having("COUNT(DISTINCT question_attempts.question_id) >= ?", minDistinctQuestions),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateAccuracyByResource returns a map[resourceID]accuracy% over
+// the user's attempts that followed a learning resource. Attempts
+// without a ResourceID are left out.
+func CalculateAccuracyByResource(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	counts, err := scanCountsBy(
+		userAttempts(db, userID).Where("question_attempts.resource_id <> ''"),
+		"question_attempts.resource_id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserTopicAccuracyExcludingQuestions returns a
+// map[topic]accuracy% ignoring every attempt at the listed questions,
+// e.g. ones known to be broken or leaked. An empty list excludes
+// nothing.
+func CalculateUserTopicAccuracyExcludingQuestions(db *gorm.DB, userID uuid.UUID, excludeIDs []uint) (map[string]float64, error) {
+	q := userAttempts(db, userID)
+	if len(excludeIDs) > 0 {
+		q = q.Where("question_attempts.question_id NOT IN ?", excludeIDs)
+	}
+
+	counts, err := scanTopicCounts(q)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserTopicAccuracyExcludingPostSolution returns a
+// map[topic]accuracy% counting only genuine attempts: attempts made
+// after the user had seen the solution are left out.
+func CalculateUserTopicAccuracyExcludingPostSolution(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.saw_solution = ?", false),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateUserTopicAccuracyExcludingWindow returns a map[topic]accuracy%
+// ignoring attempts made within [from, to], both ends included, such as
+// an incident during which answers were graded wrongly. The window is
+// compared in UTC, since SQLite compares stored times as text.
+func CalculateUserTopicAccuracyExcludingWindow(db *gorm.DB, userID uuid.UUID, from, to time.Time) (map[string]float64, error) {
+	if to.Before(from) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).
+			Where("NOT (question_attempts.created_at BETWEEN ? AND ?)", from.UTC(), to.UTC()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserTopicAccuracyForGrade(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	mustCreate(t, db, &[]Question{
+		{ID: 1, Topic: "Algebra", GradeLevel: 8},
+		{ID: 2, Topic: "Algebra", GradeLevel: 10},
+		{ID: 3, Topic: "Geometry", GradeLevel: 10},
+	})
+	mustCreate(t, db, &[]QuestionAttempt{
+		{UserID: user, QuestionID: 1, IsCorrect: true},
+		{UserID: user, QuestionID: 1, IsCorrect: true},
+		{UserID: user, QuestionID: 2, IsCorrect: false},
+		{UserID: user, QuestionID: 2, IsCorrect: true},
+		{UserID: user, QuestionID: 3, IsCorrect: true},
+	})
+
+	got, err := CalculateUserTopicAccuracyForGrade(db, user, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50, "Geometry": 100})
+}
+
+func TestCalculateUserAccuracyByType(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	mustCreate(t, db, &[]Question{
+		{ID: 1, Topic: "Algebra", QuestionType: "mcq"},
+		{ID: 2, Topic: "Geometry", QuestionType: "mcq"},
+		{ID: 3, Topic: "Algebra"},
+	})
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 2, false, 1),
+		attempt(user, 3, true, 2),
+	})
+
+	got, err := CalculateUserAccuracyByType(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"mcq": 50, "unknown": 100})
+}
+
+func TestCalculateUserTopicAccuracyBusinessHours(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// testEpoch is 09:00 UTC, i.e. 11:00 at UTC+2.
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, true, 0),     // 11:00 local
+		attempt(user, 1, false, 5*60), // 16:00 local
+		attempt(user, 1, false, 7*60), // 18:00 local, after hours
+	})
+
+	got, err := CalculateUserTopicAccuracyBusinessHours(db, user, 9, 17, time.FixedZone("UTC+2", 2*60*60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50})
+
+	if _, err := CalculateUserTopicAccuracyBusinessHours(db, user, 17, 9, nil); !errors.Is(err, ErrInvalidHourRange) {
+		t.Fatalf("got %v, want ErrInvalidHourRange", err)
+	}
+}
+
+func TestCalculateUserAccuracyGrouped(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	mustCreate(t, db, &[]Question{
+		{ID: 1, Topic: "Algebra", GradeLevel: 8},
+		{ID: 2, Topic: "Geometry", GradeLevel: 8},
+		{ID: 3, Topic: "Algebra", GradeLevel: 10},
+	})
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 2, false, 1),
+		attempt(user, 3, true, 2),
+	})
+
+	got, err := CalculateUserAccuracyGrouped(db, user, func(a QuestionAttempt, q Question) string {
+		return fmt.Sprintf("%s/%d", q.Topic, q.GradeLevel)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra/8": 100, "Geometry/8": 0, "Algebra/10": 100})
+}
+
+func TestCalculateUserTopicAccuracyScoreThreshold(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := outcomes(user, 1, 0, false, false, true, true)
+	for i, score := range []float64{0.9, 0.7, 0.69, 0.2} {
+		attempts[i].Score = score
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateUserTopicAccuracyScoreThreshold(db, user, 0.7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// IsCorrect is ignored; 0.9 and 0.7 pass.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50})
+
+	if _, err := CalculateUserTopicAccuracyScoreThreshold(db, user, 1.5); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+}
+
+func TestCalculateUserAccuracyByTimed(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := outcomes(user, 1, 0, true, false, true)
+	attempts[0].Timed = true
+	attempts[1].Timed = true
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateUserAccuracyByTimed(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !approxEqual(got[true], 50) || !approxEqual(got[false], 100) {
+		t.Fatalf("got %v, want timed 50, untimed 100", got)
+	}
+}
+
+func TestCalculateTopicAccuracyForBatch(t *testing.T) {
+	db := newTestDB(t)
+	u1, u2 := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := []QuestionAttempt{
+		attempt(u1, 1, true, 0),
+		attempt(u2, 1, false, 1),
+		attempt(u2, 1, false, 2),
+	}
+	attempts[0].BatchID = "import-7"
+	attempts[1].BatchID = "import-7"
+	attempts[2].BatchID = "import-8"
+	mustCreate(t, db, attempts)
+
+	// Every user's attempts in the batch count.
+	got, err := CalculateTopicAccuracyForBatch(db, "import-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50})
+}
+
+func TestCalculateUserTopicAccuracyVariableThreshold(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true, false))
+	mustCreate(t, db, outcomes(user, 3, 20, true))
+
+	// Algebra needs 3 and has them, Geometry needs 5; Calculus falls
+	// back to the default of 2.
+	thresholds := map[string]int{"Algebra": 3, "Geometry": 5}
+	got, err := CalculateUserTopicAccuracyVariableThreshold(db, user, thresholds, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 200.0 / 3})
+
+	got, err = CalculateUserTopicAccuracyVariableThreshold(db, user, thresholds, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 200.0 / 3, "Calculus": 100})
+}
+
+func TestCalculateUserBookmarkedAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra")
+	attempts := append(outcomes(user, 1, 0, false, true, true), outcomes(user, 2, 10, true)...)
+	attempts[2].MarkedForReview = true
+	mustCreate(t, db, attempts)
+	// Another user's bookmark does not count.
+	other := attempt(uuid.New(), 2, true, 20)
+	other.MarkedForReview = true
+	mustCreate(t, db, &other)
+
+	got, err := CalculateUserBookmarkedAccuracy(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every attempt at question 1 counts, including those before marking.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 200.0 / 3})
+}
+
+func TestCalculateUserAccuracyByComplexityBand(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	mustCreate(t, db, &[]Question{
+		{ID: 1, Topic: "Algebra", Complexity: 2},
+		{ID: 2, Topic: "Algebra", Complexity: 3},
+		{ID: 3, Topic: "Algebra", Complexity: 5},
+		{ID: 4, Topic: "Algebra", Complexity: 6},
+	})
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 2, true, 1),
+		attempt(user, 3, false, 2),
+		attempt(user, 4, false, 3),
+	})
+
+	got, err := CalculateUserAccuracyByComplexityBand(db, user, []int{3, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"<3": 100, "[3,6)": 50, ">=6": 0})
+
+	if _, err := CalculateUserAccuracyByComplexityBand(db, user, []int{6, 3}); !errors.Is(err, ErrInvalidBands) {
+		t.Fatalf("got %v, want ErrInvalidBands", err)
+	}
+}
+
+func TestCalculateUserTopicAccuracyWithAliases(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Trig", "Trigonometry", "Algebra")
+	mustCreate(t, db, outcomes(user, 1, 0, true))
+	mustCreate(t, db, outcomes(user, 2, 10, false, true, true))
+	mustCreate(t, db, outcomes(user, 3, 20, false))
+
+	got, err := CalculateUserTopicAccuracyWithAliases(db, user, map[string]string{"Trig": "Trigonometry"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Trigonometry": 75, "Algebra": 0})
+}
+
+func TestCalculateUserTopicAccuracyMinDistinctQuestions(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	// Many retries of one question do not qualify Geometry.
+	mustCreate(t, db, outcomes(user, 3, 20, true, true, true, true))
+
+	got, err := CalculateUserTopicAccuracyMinDistinctQuestions(db, user, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 200.0 / 3})
+}
+
+func TestCalculateAccuracyByResource(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := outcomes(user, 1, 0, true, false, true, false)
+	for i, resource := range []string{"video-1", "video-1", "notes-2", ""} {
+		attempts[i].ResourceID = resource
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateAccuracyByResource(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"video-1": 50, "notes-2": 100})
+}
+
+func TestCalculateUserTopicAccuracyExcludingQuestions(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true))
+	mustCreate(t, db, outcomes(user, 2, 10, false, false))
+	mustCreate(t, db, outcomes(user, 3, 20, true))
+
+	got, err := CalculateUserTopicAccuracyExcludingQuestions(db, user, []uint{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 100, "Geometry": 100})
+
+	got, err = CalculateUserTopicAccuracyExcludingQuestions(db, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 100.0 / 3, "Geometry": 100})
+}
+
+func TestCalculateUserTopicAccuracyExcludingPostSolution(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := outcomes(user, 1, 0, false, true, true)
+	attempts[1].SawSolution = true
+	attempts[2].SawSolution = true
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateUserTopicAccuracyExcludingPostSolution(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 0})
+}
+
+func TestCalculateUserTopicAccuracyExcludingWindow(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 1, false, 60), // inside the incident
+		attempt(user, 1, false, 90), // on its closing edge
+		attempt(user, 1, true, 120),
+	})
+
+	// The incident is given at UTC-5.
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	from, to := testEpoch.Add(time.Hour).In(zone), testEpoch.Add(90*time.Minute).In(zone)
+	got, err := CalculateUserTopicAccuracyExcludingWindow(db, user, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 100})
+
+	if _, err := CalculateUserTopicAccuracyExcludingWindow(db, user, to, from); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Fatalf("got %v, want ErrInvalidTimeRange", err)
+	}
+}
+
+func TestCalculateUserTopicAccuracyExcludingWindowMixedZones(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// Both attempts fall inside the window, though neither one's local
+	// clock reading does.
+	east, west := time.FixedZone("UTC+5", 5*60*60), time.FixedZone("UTC-5", -5*60*60)
+	mustCreate(t, db, &[]QuestionAttempt{
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: testEpoch.Add(10 * time.Minute).In(east)},
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: testEpoch.Add(50 * time.Minute).In(west)},
+		{UserID: user, QuestionID: 1, IsCorrect: true, CreatedAt: testEpoch.Add(2 * time.Hour).In(west)},
+	})
+
+	got, err := CalculateUserTopicAccuracyExcludingWindow(db, user, testEpoch, testEpoch.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 100})
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/driver/sqlite"
@@ -9,8 +10,21 @@ import (
 )
 
 type Question struct {
-	ID    uint   `gorm:"primaryKey"`
-	Topic string `gorm:"size:100;index"`
+	ID         uint   `gorm:"primaryKey"`
+	Topic      string `gorm:"size:100;index"`
+	GradeLevel int    `gorm:"index"`
+	// OptionCount is the number of choices on a multiple-choice
+	// question; 0 for free-response questions.
+	OptionCount int
+	// QuestionType is the answer format, e.g. "mcq", "truefalse" or
+	// "numeric".
+	QuestionType string `gorm:"size:20;index"`
+	// Complexity is an authored difficulty/length rating; higher is
+	// more complex.
+	Complexity int
+	// Difficulty is the authored difficulty level, e.g. "easy",
+	// "medium" or "hard".
+	Difficulty string `gorm:"size:20;index"`
 }
 
 type QuestionAttempt struct {
@@ -18,7 +32,53 @@ type QuestionAttempt struct {
 	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
 	QuestionID uint      `gorm:"not null;index"`
 	Question   Question  `gorm:"foreignKey:QuestionID"`
+	SessionID  uuid.UUID `gorm:"type:uuid;index"`
+	// BatchID identifies the ingestion batch the attempt arrived in.
+	BatchID string `gorm:"size:64;index"`
+	// ResourceID is the lesson or video the user studied before the
+	// attempt, if any.
+	ResourceID string `gorm:"size:64;index"`
 	IsCorrect  bool
+	// ChosenOption is the label ("A", "B", …) picked on a
+	// multiple-choice question; empty otherwise.
+	ChosenOption string `gorm:"size:8"`
+	// Timed reports whether the attempt was made under a time limit.
+	Timed bool
+	// TimeSpentMs is how long the user took to answer, in milliseconds.
+	TimeSpentMs int64
+	// MarkedForReview is set when the user flagged the question as
+	// tricky while attempting it.
+	MarkedForReview bool
+	// SawSolution is set when the user had already viewed the worked
+	// solution before making this attempt.
+	SawSolution bool
+	// Score is the partial credit earned, from 0 to 1.
+	Score float64
+	// PartIndex identifies the part of a multi-part question this
+	// attempt answers; 0 for single-part questions.
+	PartIndex int
+	// PredictedProbability is the user's stated confidence (0–1) that
+	// the answer is correct, if they gave one.
+	PredictedProbability *float64
+	CreatedAt            time.Time `gorm:"index"`
+}
+
+// BeforeSave stores CreatedAt in UTC. SQLite compares times as text, so
+// the time-range filters only see the right rows if every stored time
+// and every bound share one zone.
+func (a *QuestionAttempt) BeforeSave(*gorm.DB) error {
+	a.CreatedAt = a.CreatedAt.UTC()
+	return nil
+}
+
+// Session groups the attempts a user made in one sitting.
+type Session struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	// Proctored is set for supervised sessions whose attempts may be
+	// used for grading.
+	Proctored bool
+	CreatedAt time.Time
 }
 
 // CalculateUserTopicAccuracy returns a map[topic]accuracy%
@@ -61,8 +121,10 @@ func CalculateUserTopicAccuracy(db *gorm.DB, userID uuid.UUID) (map[string]float
 }
 
 func main() {
-	db, _ := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	db.AutoMigrate(&Question{}, &QuestionAttempt{})
+	db, _ := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	db.AutoMigrate(&Question{}, &QuestionAttempt{}, &Session{}, &UserTopicAccuracy{}, &TopicAccuracySnapshot{})
 
 	userID := uuid.New()
 	questions := []Question{
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// minLearningCurvePoints is the fewest attempts FitLearningCurve will
+// fit a curve to.
+const minLearningCurvePoints = 5
+
+// LearningCurveParams are the parameters of the power-law learning
+// curve accuracy(n) = A - B*n^-C, with accuracy as a percentage and n
+// the 1-based attempt number. A is the plateau the user is heading for.
+type LearningCurveParams struct {
+	A, B, C float64
+	// RSS is the residual sum of squares of the fit.
+	RSS float64
+}
+
+// FitLearningCurve fits a power-law learning curve to the user's running
+// accuracy% in topic. For a given C the best A and B follow from least
+// squares on x = n^-C, so only C is searched numerically, by golden
+// section search over (0, 5]. It returns ErrNoData if the topic has no
+// attempts and ErrInsufficientData with fewer than
+// minLearningCurvePoints.
+func FitLearningCurve(db *gorm.DB, userID uuid.UUID, topic string) (LearningCurveParams, error) {
+	outcomes, err := topicOutcomes(db, userID, topic)
+	if err != nil {
+		return LearningCurveParams{}, err
+	}
+	if len(outcomes) == 0 {
+		return LearningCurveParams{}, ErrNoData
+	}
+	if len(outcomes) < minLearningCurvePoints {
+		return LearningCurveParams{}, ErrInsufficientData
+	}
+	return fitPowerLaw(runningAccuracy(outcomes)), nil
+}
+
+// fitPowerLaw fits y[i] = A - B*(i+1)^-C.
+func fitPowerLaw(y []float64) LearningCurveParams {
+	const (
+		lo, hi = 1e-3, 5.0
+		iters  = 60
+	)
+	phi := (math.Sqrt(5) - 1) / 2
+
+	a, b := lo, hi
+	c1, c2 := b-phi*(b-a), a+phi*(b-a)
+	f1, f2 := fitForExponent(y, c1), fitForExponent(y, c2)
+	for i := 0; i < iters; i++ {
+		if f1.RSS < f2.RSS {
+			b, c2, f2 = c2, c1, f1
+			c1 = b - phi*(b-a)
+			f1 = fitForExponent(y, c1)
+		} else {
+			a, c1, f1 = c1, c2, f2
+			c2 = a + phi*(b-a)
+			f2 = fitForExponent(y, c2)
+		}
+	}
+	if f1.RSS < f2.RSS {
+		return f1
+	}
+	return f2
+}
+
+// fitForExponent returns the least-squares A and B for a fixed C.
+func fitForExponent(y []float64, c float64) LearningCurveParams {
+	n := float64(len(y))
+	var sx, sy, sxx, sxy float64
+	for i, v := range y {
+		x := math.Pow(float64(i+1), -c)
+		sx += x
+		sy += v
+		sxx += x * x
+		sxy += x * v
+	}
+
+	// y = A + slope*x, so B = -slope.
+	var slope float64
+	if d := n*sxx - sx*sx; d != 0 {
+		slope = (n*sxy - sx*sy) / d
+	}
+	intercept := (sy - slope*sx) / n
+
+	var rss float64
+	for i, v := range y {
+		r := v - (intercept + slope*math.Pow(float64(i+1), -c))
+		rss += r * r
+	}
+	return LearningCurveParams{A: intercept, B: -slope, C: c, RSS: rss}
+}
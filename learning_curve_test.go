@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFitPowerLaw(t *testing.T) {
+	y := make([]float64, 20)
+	for i := range y {
+		y[i] = 90 - 60*math.Pow(float64(i+1), -0.7)
+	}
+
+	got := fitPowerLaw(y)
+	if math.Abs(got.A-90) > 1e-3 || math.Abs(got.B-60) > 1e-3 || math.Abs(got.C-0.7) > 1e-4 || got.RSS > 1e-6 {
+		t.Fatalf("got %+v, want A=90 B=60 C=0.7", got)
+	}
+}
+
+func TestFitLearningCurve(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, false, false, true, true, true, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true, true))
+
+	got, err := FitLearningCurve(db, user, "Algebra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fitPowerLaw([]float64{0, 0, 100.0 / 3, 50, 60, 200.0 / 3}); got != want {
+		t.Fatalf("got %+v, want the fit of the running accuracy %+v", got, want)
+	}
+
+	if _, err := FitLearningCurve(db, user, "Geometry"); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("got %v, want ErrInsufficientData", err)
+	}
+	if _, err := FitLearningCurve(db, user, "Calculus"); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
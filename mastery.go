@@ -0,0 +1,132 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Statuses reported by BuildMasteryMap.
+const (
+	MasteryLocked     = "locked"
+	MasteryInProgress = "in-progress"
+	MasteryMastered   = "mastered"
+)
+
+// BuildMasteryMap returns the skill-tree status of every topic named in
+// prereqs (as a key or a prerequisite) or attempted by the user.
+//
+// A topic is locked while any of its prerequisites is not itself
+// mastered; otherwise it is mastered once its accuracy% reaches
+// masteryThreshold and in-progress before that. Locking wins over
+// accuracy, so a topic practised ahead of its prerequisites stays
+// locked. Topics caught in a prerequisite cycle are locked.
+func BuildMasteryMap(db *gorm.DB, userID uuid.UUID, prereqs map[string][]string, masteryThreshold float64) (map[string]string, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	accuracies := accuracyByTopic(counts)
+
+	statuses := make(map[string]string)
+	visiting := make(map[string]bool)
+	var resolve func(topic string) string
+	resolve = func(topic string) string {
+		if s, ok := statuses[topic]; ok {
+			return s
+		}
+		if visiting[topic] {
+			return MasteryLocked
+		}
+		visiting[topic] = true
+		defer delete(visiting, topic)
+
+		status := MasteryInProgress
+		for _, p := range prereqs[topic] {
+			if resolve(p) != MasteryMastered {
+				status = MasteryLocked
+			}
+		}
+		if acc, ok := accuracies[topic]; status != MasteryLocked && ok && acc >= masteryThreshold {
+			status = MasteryMastered
+		}
+		statuses[topic] = status
+		return status
+	}
+
+	for topic, ps := range prereqs {
+		resolve(topic)
+		for _, p := range ps {
+			resolve(p)
+		}
+	}
+	for topic := range accuracies {
+		resolve(topic)
+	}
+	return statuses, nil
+}
+
+// ExplainTopicStruggle returns the prerequisites of topic, direct or
+// indirect, that are likely holding the user back: those with accuracy%
+// below weakThreshold, plus those never attempted. They are ordered
+// weakest first (never attempted counts as 0%), then by name.
+func ExplainTopicStruggle(db *gorm.DB, userID uuid.UUID, topic string, prereqs map[string][]string, weakThreshold float64) ([]string, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	accuracies := accuracyByTopic(counts)
+
+	seen := map[string]bool{topic: true}
+	queue := append([]string(nil), prereqs[topic]...)
+	var weak []string
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		queue = append(queue, prereqs[p]...)
+
+		if accuracies[p] < weakThreshold {
+			weak = append(weak, p)
+		}
+	}
+
+	sort.Slice(weak, func(i, j int) bool {
+		if ai, aj := accuracies[weak[i]], accuracies[weak[j]]; ai != aj {
+			return ai < aj
+		}
+		return weak[i] < weak[j]
+	})
+	return weak, nil
+}
+
+// CalculateEfficientMasteryDate returns when the user's running
+// accuracy% in topic first reached masteryThreshold, i.e. the earliest
+// point they could be considered to have mastered it; later dips below
+// the threshold are ignored. It returns ErrNoData if the topic has no
+// attempts and ErrNotMastered if the threshold was never reached.
+func CalculateEfficientMasteryDate(db *gorm.DB, userID uuid.UUID, topic string, masteryThreshold float64) (time.Time, error) {
+	records, err := userAttemptRecords(userAttempts(db, userID).Where("questions.topic = ?", topic))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(records) == 0 {
+		return time.Time{}, ErrNoData
+	}
+
+	correct := 0
+	for i, r := range records {
+		if r.IsCorrect {
+			correct++
+		}
+		if float64(correct)*100/float64(i+1) >= masteryThreshold {
+			return r.CreatedAt, nil
+		}
+	}
+	return time.Time{}, ErrNotMastered
+}
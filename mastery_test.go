@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildMasteryMap(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Calculus", "Biology")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	mustCreate(t, db, outcomes(user, 3, 20, true))
+
+	prereqs := map[string][]string{
+		"Calculus":     {"Algebra"},
+		"Trigonometry": {"Geometry"},
+		"X":            {"Y"},
+		"Y":            {"X"},
+	}
+	got, err := BuildMasteryMap(db, user, prereqs, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"Algebra":      MasteryInProgress,
+		"Calculus":     MasteryLocked, // practised ahead of Algebra
+		"Biology":      MasteryMastered,
+		"Geometry":     MasteryInProgress,
+		"Trigonometry": MasteryLocked,
+		"X":            MasteryLocked,
+		"Y":            MasteryLocked,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestExplainTopicStruggle(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Arithmetic", "Fractions", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))       // 50%
+	mustCreate(t, db, outcomes(user, 2, 10, true, true, true)) // 100%
+	mustCreate(t, db, outcomes(user, 3, 20, false, true))      // 50%
+
+	// Calculus ← Algebra ← {Arithmetic, Fractions, Sets}; Sets was never
+	// attempted and ranks as 0%.
+	prereqs := map[string][]string{
+		"Calculus": {"Algebra"},
+		"Algebra":  {"Fractions", "Arithmetic", "Sets"},
+	}
+	got, err := ExplainTopicStruggle(db, user, "Calculus", prereqs, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Sets", "Algebra", "Fractions"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got, err = ExplainTopicStruggle(db, user, "Calculus", prereqs, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Sets"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateEfficientMasteryDate(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// Running accuracy 0, 50, 66.7, 75, 60: 75% is first reached at
+	// minute 3, and the later dip is ignored.
+	mustCreate(t, db, outcomes(user, 1, 0, false, true, true, true, false))
+
+	got, err := CalculateEfficientMasteryDate(db, user, "Algebra", 75)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testEpoch.Add(3 * time.Minute); !got.Equal(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if _, err := CalculateEfficientMasteryDate(db, user, "Algebra", 90); !errors.Is(err, ErrNotMastered) {
+		t.Fatalf("got %v, want ErrNotMastered", err)
+	}
+	if _, err := CalculateEfficientMasteryDate(db, user, "Geometry", 75); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
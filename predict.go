@@ -0,0 +1,194 @@
+package main
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// predictionSmoothing is the weight given to each new attempt in the
+// exponentially weighted estimate; older attempts fade geometrically.
+const predictionSmoothing = 0.3
+
+// PredictNextCorrectProbability estimates the chance (0–1) that the
+// user answers their next question in topic correctly, using an
+// exponentially weighted mean of their attempts so recent form counts
+// for more than old results: the newest attempt has weight 1 and each
+// older one 1-predictionSmoothing times the weight of the one after it.
+func PredictNextCorrectProbability(db *gorm.DB, userID uuid.UUID, topic string) (float64, error) {
+	outcomes, err := topicOutcomes(db, userID, topic)
+	if err != nil {
+		return 0, err
+	}
+	if len(outcomes) == 0 {
+		return 0, ErrNoData
+	}
+
+	var sum, totalWeight float64
+	weight := 1.0
+	for i := len(outcomes) - 1; i >= 0; i-- {
+		sum += weight * outcomeValue(outcomes[i])
+		totalWeight += weight
+		weight *= 1 - predictionSmoothing
+	}
+	return clamp(sum/totalWeight, 0, 1), nil
+}
+
+// RequiredWeeklyImprovement returns how many percentage points per week
+// the user's accuracy in topic must rise, on top of its current weekly
+// velocity, to reach goalAccuracy (a percentage) by deadline: the gap to
+// the goal spread over the weeks left, minus the pace the topic is
+// already improving at. A result of 0 or less means the current pace is
+// enough. It returns ErrNoData if the topic has no attempts and
+// ErrUnreachable if the deadline is not in the future.
+func RequiredWeeklyImprovement(db *gorm.DB, userID uuid.UUID, topic string, goalAccuracy float64, deadline time.Time) (float64, error) {
+	return requiredWeeklyImprovement(db, userID, topic, goalAccuracy, deadline, time.Now())
+}
+
+// requiredWeeklyImprovement is RequiredWeeklyImprovement measured from
+// now instead of the current time.
+func requiredWeeklyImprovement(db *gorm.DB, userID uuid.UUID, topic string, goalAccuracy float64, deadline, now time.Time) (float64, error) {
+	remaining := deadline.Sub(now)
+	if remaining <= 0 {
+		return 0, ErrUnreachable
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, userID).Where("questions.topic = ?", topic))
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, ErrNoData
+	}
+	current := accuracyByTopic(countByTopic(records))[topic]
+	velocity := weeklyVelocity(records)
+
+	weeks := remaining.Hours() / (24 * 7)
+	return (goalAccuracy-current)/weeks - velocity, nil
+}
+
+// weeklyVelocity returns how fast accuracy over records is changing, in
+// percentage points per week: the least-squares slope of accuracy per
+// calendar week (UTC, weeks starting Monday) against the week. Records
+// from a single week have a velocity of 0.
+func weeklyVelocity(records []attemptRecord) float64 {
+	const week = 7 * 24 * time.Hour
+
+	trend := bucketTrend(records, BucketWeek)
+	xs := make([]float64, len(trend))
+	ys := make([]float64, len(trend))
+	for i, p := range trend {
+		xs[i] = float64(p.PeriodStart.Sub(trend[0].PeriodStart)) / float64(week)
+		ys[i] = p.Accuracy
+	}
+	return slope(xs, ys)
+}
+
+// weeklyVelocityByTopic returns the weeklyVelocity of each topic in
+// records.
+func weeklyVelocityByTopic(records []attemptRecord) map[string]float64 {
+	velocities := make(map[string]float64)
+	for topic, rs := range recordsByTopic(records) {
+		velocities[topic] = weeklyVelocity(rs)
+	}
+	return velocities
+}
+
+// DefaultExamTopicAccuracy is the accuracy% assumed for blueprint topics
+// the user has never attempted, unless the caller supplies its own.
+const DefaultExamTopicAccuracy = 50.0
+
+// PredictExamScore returns the user's expected score (0–100) on an exam
+// whose blueprint maps each topic to its share of the exam. Weights are
+// normalized to sum to 1; topics without attempts are assumed to be
+// answered at DefaultExamTopicAccuracy.
+func PredictExamScore(db *gorm.DB, userID uuid.UUID, blueprint map[string]float64) (float64, error) {
+	return PredictExamScoreWithDefault(db, userID, blueprint, DefaultExamTopicAccuracy)
+}
+
+// PredictExamScoreWithDefault is PredictExamScore with topics without
+// attempts assumed to be answered at unattemptedAccuracy (a percentage).
+func PredictExamScoreWithDefault(db *gorm.DB, userID uuid.UUID, blueprint map[string]float64, unattemptedAccuracy float64) (float64, error) {
+	weights, err := normalizeWeights(blueprint)
+	if err != nil {
+		return 0, err
+	}
+
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	accuracies := accuracyByTopic(counts)
+
+	var score float64
+	for topic, w := range weights {
+		acc, ok := accuracies[topic]
+		if !ok {
+			acc = unattemptedAccuracy
+		}
+		score += w * acc
+	}
+	return score, nil
+}
+
+// CalculateExamReadiness returns a 0–100 readiness score for an exam on
+// examDate. Each blueprint topic's current accuracy% is projected
+// forward by its recent velocity (the slope of its weekly trend) for
+// the weeks left until the exam, capped to [0, 100], and the
+// projections are combined by the normalized blueprint weights.
+// Topics without attempts use DefaultExamTopicAccuracy with no projected
+// change, and an exam date in the past projects nothing.
+func CalculateExamReadiness(db *gorm.DB, userID uuid.UUID, blueprint map[string]float64, examDate time.Time) (float64, error) {
+	return examReadiness(db, userID, blueprint, examDate, time.Now())
+}
+
+// examReadiness is CalculateExamReadiness projected from now instead of
+// the current time.
+func examReadiness(db *gorm.DB, userID uuid.UUID, blueprint map[string]float64, examDate, now time.Time) (float64, error) {
+	weights, err := normalizeWeights(blueprint)
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	accuracies := accuracyByTopic(countByTopic(records))
+	velocities := weeklyVelocityByTopic(records)
+
+	weeks := examDate.Sub(now).Hours() / (24 * 7)
+	if weeks < 0 {
+		weeks = 0
+	}
+
+	var readiness float64
+	for topic, w := range weights {
+		acc, ok := accuracies[topic]
+		if !ok {
+			acc = DefaultExamTopicAccuracy
+		}
+		readiness += w * clamp(acc+velocities[topic]*weeks, 0, 100)
+	}
+	return readiness, nil
+}
+
+// outcomeValue maps an attempt's correctness to 1 or 0.
+func outcomeValue(correct bool) float64 {
+	if correct {
+		return 1
+	}
+	return 0
+}
+
+// clamp limits v to [lo, hi].
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
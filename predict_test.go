@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPredictNextCorrectProbability(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, false, false, false, true, true, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true, false, false))
+
+	// An improving topic predicts above its all-time 50%.
+	got, err := PredictNextCorrectProbability(db, user, "Algebra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (1 + 0.7 + 0.49) / (1 + 0.7 + 0.49 + 0.343 + 0.2401 + 0.16807); !approxEqual(got, want) || got <= 0.5 {
+		t.Fatalf("Algebra: got %v, want %v", got, want)
+	}
+
+	// A falling topic predicts below its all-time 1/3.
+	got, err = PredictNextCorrectProbability(db, user, "Geometry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0.49 / (1 + 0.7 + 0.49); !approxEqual(got, want) || got >= 1.0/3 {
+		t.Fatalf("Geometry: got %v, want %v", got, want)
+	}
+
+	if _, err := PredictNextCorrectProbability(db, user, "Calculus"); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestRequiredWeeklyImprovement(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// Week one 1/4, week two 3/4: running at 50% and improving by
+	// 50 points a week.
+	mustCreate(t, db, outcomes(user, 1, 0, true, false, false, false))
+	mustCreate(t, db, outcomes(user, 1, 7*24*60, true, true, true, false))
+
+	now := testEpoch.AddDate(0, 0, 14)
+	deadline := now.AddDate(0, 0, 28)
+
+	got, err := requiredWeeklyImprovement(db, user, "Algebra", 90, deadline, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (90 - 50) / 4 weeks = 10 a week, less the 50 already gained.
+	if !approxEqual(got, -40) {
+		t.Fatalf("got %v, want -40", got)
+	}
+
+	if _, err := requiredWeeklyImprovement(db, user, "Algebra", 90, now, now); !errors.Is(err, ErrUnreachable) {
+		t.Fatalf("got %v, want ErrUnreachable", err)
+	}
+	if _, err := requiredWeeklyImprovement(db, user, "Geometry", 90, deadline, now); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestPredictExamScore(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, false))
+
+	blueprint := map[string]float64{"Algebra": 2, "Geometry": 1, "Calculus": 1}
+	got, err := PredictExamScore(db, user, blueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Calculus is unattempted and assumed to be answered at 50%.
+	if want := 0.5*75 + 0.25*0 + 0.25*50; !approxEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got, err = PredictExamScoreWithDefault(db, user, blueprint, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0.5*75 + 0.25*0 + 0.25*40; !approxEqual(got, want) {
+		t.Fatalf("with default 40: got %v, want %v", got, want)
+	}
+
+	if _, err := PredictExamScore(db, user, map[string]float64{}); !errors.Is(err, ErrInvalidWeights) {
+		t.Fatalf("got %v, want ErrInvalidWeights", err)
+	}
+}
+
+func TestExamReadiness(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// Running at 50% and improving by 50 points a week.
+	mustCreate(t, db, outcomes(user, 1, 0, true, false, false, false))
+	mustCreate(t, db, outcomes(user, 1, 7*24*60, true, true, true, false))
+
+	now := testEpoch.AddDate(0, 0, 14)
+	blueprint := map[string]float64{"Algebra": 1, "Geometry": 1}
+	for name, tc := range map[string]struct {
+		examDate time.Time
+		want     float64
+	}{
+		"half a week": {now.Add(84 * time.Hour), (75 + 50) / 2.0},
+		"capped":      {now.AddDate(0, 0, 28), (100 + 50) / 2.0},
+		"past":        {now.AddDate(0, 0, -7), (50 + 50) / 2.0},
+	} {
+		got, err := examReadiness(db, user, blueprint, tc.examDate, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !approxEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// decayWeight returns the weight of something age old when weights
+// halve every halfLife.
+func decayWeight(age, halfLife time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// weightedAccuracyByTopic returns a map[topic]accuracy% where each
+// record counts with the weight weight gives it.
+func weightedAccuracyByTopic(records []attemptRecord, weight func(attemptRecord) float64) map[string]float64 {
+	weights := make(map[string]float64)
+	correct := make(map[string]float64)
+	for _, r := range records {
+		w := weight(r)
+		weights[r.Topic] += w
+		if r.IsCorrect {
+			correct[r.Topic] += w
+		}
+	}
+
+	accuracies := make(map[string]float64, len(weights))
+	for topic, w := range weights {
+		if w > 0 {
+			accuracies[topic] = correct[topic] / w * 100
+		}
+	}
+	return accuracies
+}
+
+// CalculateUserTopicAccuracyDecayedWithCutoff returns a
+// map[topic]accuracy% where attempts older than hardCutoff are ignored
+// outright and the remaining ones are weighted by 0.5^(age/halfLife).
+//
+// The cutoff only removes data; it does not reset the decay, so an
+// attempt just inside the cutoff keeps the small weight its age earns.
+// A topic whose attempts are all older than the cutoff is omitted.
+func CalculateUserTopicAccuracyDecayedWithCutoff(db *gorm.DB, userID uuid.UUID, hardCutoff, halfLife time.Duration) (map[string]float64, error) {
+	return accuracyDecayedWithCutoff(db, userID, hardCutoff, halfLife, time.Now())
+}
+
+// accuracyDecayedWithCutoff is CalculateUserTopicAccuracyDecayedWithCutoff
+// with ages and the cutoff measured from now instead of the current time.
+func accuracyDecayedWithCutoff(db *gorm.DB, userID uuid.UUID, hardCutoff, halfLife time.Duration, now time.Time) (map[string]float64, error) {
+	if hardCutoff <= 0 || halfLife <= 0 {
+		return nil, ErrInvalidDuration
+	}
+
+	records, err := userAttemptRecords(
+		userAttempts(db, userID).Where("question_attempts.created_at >= ?", now.Add(-hardCutoff).UTC()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return weightedAccuracyByTopic(records, func(r attemptRecord) float64 {
+		return decayWeight(now.Sub(r.CreatedAt), halfLife)
+	}), nil
+}
+
+// CalculateUserTopicSessionWeightedAccuracy returns a map[topic]accuracy%
+// where each attempt is weighted by 0.5^(age/sessionHalfLife), age being
+// measured from the start of its session (the session's earliest
+// attempt) rather than from the attempt itself, so a whole session ages
+// together. Attempts outside any session are aged from their own time.
+func CalculateUserTopicSessionWeightedAccuracy(db *gorm.DB, userID uuid.UUID, sessionHalfLife time.Duration) (map[string]float64, error) {
+	return sessionWeightedAccuracy(db, userID, sessionHalfLife, time.Now())
+}
+
+// sessionWeightedAccuracy is CalculateUserTopicSessionWeightedAccuracy
+// with ages measured from now instead of the current time.
+func sessionWeightedAccuracy(db *gorm.DB, userID uuid.UUID, sessionHalfLife time.Duration, now time.Time) (map[string]float64, error) {
+	if sessionHalfLife <= 0 {
+		return nil, ErrInvalidDuration
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	sessionStart := make(map[uuid.UUID]time.Time)
+	for _, r := range records {
+		if r.SessionID == uuid.Nil {
+			continue
+		}
+		if start, ok := sessionStart[r.SessionID]; !ok || r.CreatedAt.Before(start) {
+			sessionStart[r.SessionID] = r.CreatedAt
+		}
+	}
+
+	return weightedAccuracyByTopic(records, func(r attemptRecord) float64 {
+		at := r.CreatedAt
+		if start, ok := sessionStart[r.SessionID]; ok {
+			at = start
+		}
+		return decayWeight(now.Sub(at), sessionHalfLife)
+	}), nil
+}
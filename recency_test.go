@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserTopicAccuracyDecayedWithCutoff(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	const day = 24 * time.Hour
+	now := testEpoch.AddDate(0, 0, 30)
+	mustCreate(t, db, &[]QuestionAttempt{
+		{UserID: user, QuestionID: 1, IsCorrect: true, CreatedAt: now.Add(-day)},
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: now.Add(-2 * day)},
+		// Exactly at the cutoff is kept; a second older is dropped.
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: now.Add(-5 * day)},
+		{UserID: user, QuestionID: 1, IsCorrect: true, CreatedAt: now.Add(-5*day - time.Second)},
+		{UserID: user, QuestionID: 2, IsCorrect: true, CreatedAt: now.Add(-10 * day)},
+	})
+
+	got, err := accuracyDecayedWithCutoff(db, user, 5*day, day, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Weights 0.5, 0.25 and 1/32 inside the cutoff; Geometry is all
+	// outside.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 0.5 / (0.5 + 0.25 + 1.0/32) * 100})
+
+	if _, err := accuracyDecayedWithCutoff(db, user, 0, day, now); !errors.Is(err, ErrInvalidDuration) {
+		t.Fatalf("got %v, want ErrInvalidDuration", err)
+	}
+}
+
+func TestCalculateUserTopicSessionWeightedAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user, session := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	const day = 24 * time.Hour
+	now := testEpoch.AddDate(0, 0, 30)
+	mustCreate(t, db, &[]QuestionAttempt{
+		{UserID: user, QuestionID: 1, SessionID: session, IsCorrect: true, CreatedAt: now.Add(-2 * day)},
+		{UserID: user, QuestionID: 1, SessionID: session, IsCorrect: false, CreatedAt: now.Add(-day)},
+		{UserID: user, QuestionID: 1, IsCorrect: true, CreatedAt: now.Add(-day)},
+	})
+
+	got, err := sessionWeightedAccuracy(db, user, day, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both session attempts age from its start (weight 0.25 each); the
+	// sessionless one from its own time (0.5).
+	assertAccuracies(t, got, map[string]float64{"Algebra": 75})
+
+	if _, err := sessionWeightedAccuracy(db, user, 0, now); !errors.Is(err, ErrInvalidDuration) {
+		t.Fatalf("got %v, want ErrInvalidDuration", err)
+	}
+}
+
+func TestSessionWeightedAccuracyFavoursRecentSessions(t *testing.T) {
+	db := newTestDB(t)
+	recentGood, oldGood := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	const day = 24 * time.Hour
+	now := testEpoch.AddDate(0, 0, 30)
+	// Both users have one good and one bad session; only the order differs.
+	sessions := func(user uuid.UUID, goodAge, badAge time.Duration) []QuestionAttempt {
+		good, bad := uuid.New(), uuid.New()
+		return []QuestionAttempt{
+			{UserID: user, QuestionID: 1, SessionID: good, IsCorrect: true, CreatedAt: now.Add(-goodAge)},
+			{UserID: user, QuestionID: 1, SessionID: good, IsCorrect: true, CreatedAt: now.Add(-goodAge + time.Minute)},
+			{UserID: user, QuestionID: 1, SessionID: bad, IsCorrect: false, CreatedAt: now.Add(-badAge)},
+			{UserID: user, QuestionID: 1, SessionID: bad, IsCorrect: false, CreatedAt: now.Add(-badAge + time.Minute)},
+		}
+	}
+	mustCreate(t, db, sessions(recentGood, day, 3*day))
+	mustCreate(t, db, sessions(oldGood, 3*day, day))
+
+	recent, err := sessionWeightedAccuracy(db, recentGood, day, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := sessionWeightedAccuracy(db, oldGood, day, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Session weights 0.5 and 0.125.
+	assertAccuracies(t, recent, map[string]float64{"Algebra": 0.5 / 0.625 * 100})
+	assertAccuracies(t, old, map[string]float64{"Algebra": 0.125 / 0.625 * 100})
+}
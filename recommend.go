@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalculateUserTopicReviewPriority returns a map[topic]priority for a
+// spaced-repetition scheduler; higher means review sooner:
+//
+//	priority = (1 - accuracy) * ln(1 + daysSinceLastAttempt)
+//
+// with accuracy as a 0–1 fraction. A topic practised moments ago or
+// answered perfectly scores 0.
+func CalculateUserTopicReviewPriority(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	return reviewPriority(db, userID, time.Now())
+}
+
+// reviewPriority is CalculateUserTopicReviewPriority measured from now
+// instead of the current time.
+func reviewPriority(db *gorm.DB, userID uuid.UUID, now time.Time) (map[string]float64, error) {
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	lastSeen := make(map[string]time.Time)
+	for _, r := range records {
+		if r.CreatedAt.After(lastSeen[r.Topic]) {
+			lastSeen[r.Topic] = r.CreatedAt
+		}
+	}
+
+	priorities := make(map[string]float64)
+	for _, c := range countByTopic(records) {
+		days := math.Max(now.Sub(lastSeen[c.Topic]).Hours()/24, 0)
+		priorities[c.Topic] = (1 - c.accuracy()/100) * math.Log1p(days)
+	}
+	return priorities, nil
+}
+
+// OptimizeReviewOrder picks which of the topics in minutesPerTopic to
+// review within availableMinutes and returns them in review order.
+//
+// A topic's expected gain is its room for improvement, 1 - accuracy (0–1),
+// with never-attempted topics assumed to gain 1. Topics are taken
+// greedily by gain per minute, highest first, skipping any that no
+// longer fit the remaining budget; this is the usual greedy knapsack
+// approximation. Topics with a non-positive duration are ignored.
+func OptimizeReviewOrder(db *gorm.DB, userID uuid.UUID, availableMinutes int, minutesPerTopic map[string]int) ([]string, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	accuracies := accuracyByTopic(counts)
+
+	type candidate struct {
+		topic   string
+		minutes int
+		rate    float64
+	}
+	var candidates []candidate
+	for topic, minutes := range minutesPerTopic {
+		if minutes <= 0 {
+			continue
+		}
+		gain := 1.0
+		if acc, ok := accuracies[topic]; ok {
+			gain = 1 - acc/100
+		}
+		candidates = append(candidates, candidate{topic, minutes, gain / float64(minutes)})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].rate != candidates[j].rate {
+			return candidates[i].rate > candidates[j].rate
+		}
+		return candidates[i].topic < candidates[j].topic
+	})
+
+	order := []string{}
+	remaining := availableMinutes
+	for _, c := range candidates {
+		if c.minutes <= remaining {
+			order = append(order, c.topic)
+			remaining -= c.minutes
+		}
+	}
+	return order, nil
+}
+
+// CalculateTopicInformationGain scores each attempted topic by how much
+// one more attempt would shrink the uncertainty of its accuracy
+// estimate. With p the Laplace-smoothed accuracy (correct+1)/(n+2) and
+// n attempts, the variance of the estimate falls from p(1-p)/n to
+// p(1-p)/(n+1), so
+//
+//	gain = p(1-p) / (n(n+1))
+//
+// which is largest for topics with few attempts near 50%.
+func CalculateTopicInformationGain(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	gains := make(map[string]float64, len(counts))
+	for _, c := range counts {
+		if c.Total == 0 {
+			continue
+		}
+		n := float64(c.Total)
+		p := (float64(c.Correct) + 1) / (n + 2)
+		gains[c.Topic] = p * (1 - p) / (n * (n + 1))
+	}
+	return gains, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserTopicReviewPriority(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	now := testEpoch.AddDate(0, 0, 30)
+	mustCreate(t, db, &[]QuestionAttempt{
+		// Algebra: stale and weak, last seen 20 days ago.
+		{UserID: user, QuestionID: 1, IsCorrect: true, CreatedAt: now.AddDate(0, 0, -25)},
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: now.AddDate(0, 0, -21)},
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: now.AddDate(0, 0, -20)},
+		// Geometry: fresh and strong, last seen a day ago.
+		{UserID: user, QuestionID: 2, IsCorrect: true, CreatedAt: now.AddDate(0, 0, -3)},
+		{UserID: user, QuestionID: 2, IsCorrect: true, CreatedAt: now.AddDate(0, 0, -2)},
+		{UserID: user, QuestionID: 2, IsCorrect: false, CreatedAt: now.AddDate(0, 0, -1)},
+		// Calculus: perfect, so never due however stale.
+		{UserID: user, QuestionID: 3, IsCorrect: true, CreatedAt: now.AddDate(0, 0, -29)},
+	})
+
+	got, err := reviewPriority(db, user, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Days count from the most recent attempt in each topic.
+	assertAccuracies(t, got, map[string]float64{
+		"Algebra":  2.0 / 3 * math.Log(21),
+		"Geometry": 1.0 / 3 * math.Log(2),
+		"Calculus": 0,
+	})
+	if got["Algebra"] <= got["Geometry"] {
+		t.Fatalf("stale weak topic %v should outrank fresh strong one %v", got["Algebra"], got["Geometry"])
+	}
+}
+
+func TestOptimizeReviewOrder(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+
+	// Gains per minute: Algebra 0.5/10, Calculus (unattempted) 1/30,
+	// Geometry 0/5. Calculus no longer fits once Algebra is taken.
+	minutes := map[string]int{"Algebra": 10, "Calculus": 30, "Geometry": 5, "Biology": 0}
+	got, err := OptimizeReviewOrder(db, user, 35, minutes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Algebra", "Geometry"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateTopicInformationGain(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true, true, true))
+
+	got, err := CalculateTopicInformationGain(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Smoothed p is 2/4 and 4/5.
+	assertAccuracies(t, got, map[string]float64{
+		"Algebra":  0.5 * 0.5 / (2 * 3),
+		"Geometry": 0.8 * 0.2 / (3 * 4),
+	})
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalculateUserTopicGuessCorrectedAccuracy returns a map[topic]accuracy%
+// adjusted for lucky guesses on multiple-choice questions:
+//
+//	corrected = (raw - chance) / (1 - chance)
+//
+// where chance is the average 1/OptionCount over the topic's attempts
+// (free-response questions contribute 0). Topics answered no better
+// than chance report 0.
+func CalculateUserTopicGuessCorrectedAccuracy(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	type Result struct {
+		Topic   string
+		Total   int64
+		Correct int64
+		Chance  float64
+	}
+
+	var results []Result
+	err := userAttempts(db, userID).
+		Select(`
+			questions.topic                                                           AS topic,
+			COUNT(*)                                                                  AS total,
+			SUM(CASE WHEN question_attempts.is_correct THEN 1 ELSE 0 END)             AS correct,
+			AVG(CASE WHEN questions.option_count > 1 THEN 1.0 / questions.option_count ELSE 0 END) AS chance
+		`).
+		Group("questions.topic").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	accuracies := make(map[string]float64, len(results))
+	for _, r := range results {
+		if r.Total == 0 || r.Chance >= 1 {
+			continue
+		}
+		raw := float64(r.Correct) / float64(r.Total)
+		corrected := (raw - r.Chance) / (1 - r.Chance)
+		accuracies[r.Topic] = clamp(corrected, 0, 1) * 100
+	}
+	return accuracies, nil
+}
+
+// CalculateUserTopicAccuracyQuestionCapped returns a map[topic]accuracy%
+// where every question carries equal weight: accuracy is first computed
+// per question and those figures are then averaged within each topic,
+// so retrying one question many times cannot dominate its topic.
+func CalculateUserTopicAccuracyQuestionCapped(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	type Result struct {
+		Topic    string
+		Accuracy float64
+	}
+
+	perQuestion := userAttempts(db, userID).
+		Select(`
+			questions.topic                                                              AS topic,
+			AVG(CASE WHEN question_attempts.is_correct THEN 1.0 ELSE 0.0 END) * 100.0   AS accuracy
+		`).
+		Group("questions.topic, question_attempts.question_id")
+
+	var results []Result
+	err := db.
+		Table("(?) AS per_question", perQuestion).
+		Select("topic, AVG(accuracy) AS accuracy").
+		Group("topic").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	accuracies := make(map[string]float64, len(results))
+	for _, r := range results {
+		accuracies[r.Topic] = r.Accuracy
+	}
+	return accuracies, nil
+}
+
+// CalculateUserTopicCompositeScore returns a map[topic]score blending
+// accuracy% and coverage% into one 0–100 figure per topic. The weights
+// are normalized to sum to 1, so (3, 1) and (0.75, 0.25) are
+// equivalent. Topics the user never attempted score on coverage alone,
+// which is 0.
+func CalculateUserTopicCompositeScore(db *gorm.DB, userID uuid.UUID, accuracyWeight, coverageWeight float64) (map[string]float64, error) {
+	total := accuracyWeight + coverageWeight
+	if accuracyWeight < 0 || coverageWeight < 0 || total == 0 {
+		return nil, ErrInvalidWeights
+	}
+	accuracyWeight, coverageWeight = accuracyWeight/total, coverageWeight/total
+
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	accuracies := accuracyByTopic(counts)
+
+	coverage, err := CalculateUserTopicCoverage(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(coverage))
+	for topic, cov := range coverage {
+		scores[topic] = accuracyWeight*accuracies[topic] + coverageWeight*cov
+	}
+	return scores, nil
+}
+
+// CalculateUserBrierScore returns the mean squared gap between the
+// user's stated confidence and the outcome, over attempts that recorded
+// a PredictedProbability. 0 is perfect calibration; lower is better.
+// It returns ErrNoData if no attempt carries a prediction.
+func CalculateUserBrierScore(db *gorm.DB, userID uuid.UUID) (float64, error) {
+	var result struct {
+		Total int64
+		Brier float64
+	}
+	err := db.
+		Model(&QuestionAttempt{}).
+		Select(`
+			COUNT(*) AS total,
+			AVG(
+				(predicted_probability - CASE WHEN is_correct THEN 1.0 ELSE 0.0 END) *
+				(predicted_probability - CASE WHEN is_correct THEN 1.0 ELSE 0.0 END)
+			)        AS brier
+		`).
+		Where("user_id = ? AND predicted_probability IS NOT NULL", userID).
+		Scan(&result).Error
+	if err != nil {
+		return 0, err
+	}
+	if result.Total == 0 {
+		return 0, ErrNoData
+	}
+	return result.Brier, nil
+}
+
+// CurveKind selects how a CurveSpec adjusts accuracies.
+type CurveKind int
+
+const (
+	// CurveAdditive adds Amount percentage points.
+	CurveAdditive CurveKind = iota
+	// CurveMultiplicative multiplies by Amount.
+	CurveMultiplicative
+)
+
+// CurveSpec describes a grading curve.
+type CurveSpec struct {
+	Kind   CurveKind
+	Amount float64
+}
+
+// ApplyCurve returns a copy of accuracies (percentages) with curve
+// applied to every topic, clamped to [0, 100]. The input map is not
+// modified, so it composes with any of the accuracy calculations. It
+// returns ErrInvalidCurve for an unknown curve.Kind.
+func ApplyCurve(accuracies map[string]float64, curve CurveSpec) (map[string]float64, error) {
+	var apply func(float64) float64
+	switch curve.Kind {
+	case CurveAdditive:
+		apply = func(acc float64) float64 { return acc + curve.Amount }
+	case CurveMultiplicative:
+		apply = func(acc float64) float64 { return acc * curve.Amount }
+	default:
+		return nil, ErrInvalidCurve
+	}
+
+	curved := make(map[string]float64, len(accuracies))
+	for topic, acc := range accuracies {
+		curved[topic] = clamp(apply(acc), 0, 100)
+	}
+	return curved, nil
+}
+
+// CalculateUserConservativeScore returns a cautious overall score (0–100):
+// the 95% Wilson lower bound of each topic's accuracy, averaged with
+// each topic weighted by its attempt count. Few lucky answers earn a
+// low bound, so a proven record outranks a short perfect one. It
+// returns ErrNoData if the user has no attempts.
+func CalculateUserConservativeScore(db *gorm.DB, userID uuid.UUID) (float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+
+	var weighted float64
+	var attempts int64
+	for _, c := range counts {
+		low, _ := wilsonInterval(c.Correct, c.Total, wilsonZ)
+		weighted += low * float64(c.Total)
+		attempts += c.Total
+	}
+	if attempts == 0 {
+		return 0, ErrNoData
+	}
+	return weighted / float64(attempts) * 100, nil
+}
+
+// LetterGrade maps an accuracy% onto the usual A–F scale: 90 and above
+// is an A, 80 a B, 70 a C, 60 a D, anything lower an F.
+func LetterGrade(accuracy float64) string {
+	switch {
+	case accuracy >= 90:
+		return "A"
+	case accuracy >= 80:
+		return "B"
+	case accuracy >= 70:
+		return "C"
+	case accuracy >= 60:
+		return "D"
+	}
+	return "F"
+}
+
+// CalculateUserGPA grades each attempted topic with LetterGrade, looks
+// the letter up in gradeScale (e.g. "A": 4.0) and returns the mean of
+// those grade points. Every topic counts equally regardless of attempt
+// count; letters missing from gradeScale earn 0 points. It returns
+// ErrNoData if the user has no attempts.
+func CalculateUserGPA(db *gorm.DB, userID uuid.UUID, gradeScale map[string]float64) (float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	accuracies := accuracyByTopic(counts)
+	if len(accuracies) == 0 {
+		return 0, ErrNoData
+	}
+
+	points := make([]float64, 0, len(accuracies))
+	for _, acc := range accuracies {
+		points = append(points, gradeScale[LetterGrade(acc)])
+	}
+	return mean(points), nil
+}
+
+// CalculateUserRobustOverallAccuracy returns the user's pooled accuracy%
+// across all attempts after dropping outlier topics: topics whose own
+// accuracy lies more than 1.5×IQR below the first quartile or above the
+// third quartile of the user's per-topic accuracies. It returns
+// ErrNoData if the user has no attempts.
+func CalculateUserRobustOverallAccuracy(db *gorm.DB, userID uuid.UUID) (float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	if len(counts) == 0 {
+		return 0, ErrNoData
+	}
+
+	accs := make([]float64, len(counts))
+	for i, c := range counts {
+		accs[i] = c.accuracy()
+	}
+	q1, q3 := quantile(accs, 0.25), quantile(accs, 0.75)
+	fence := 1.5 * (q3 - q1)
+
+	var kept topicCount
+	for i, c := range counts {
+		if accs[i] < q1-fence || accs[i] > q3+fence {
+			continue
+		}
+		kept.Total += c.Total
+		kept.Correct += c.Correct
+	}
+	return kept.accuracy(), nil
+}
+
+// CalculateUserTopicEfficiency returns a map[topic]attempts-per-correct
+// answer; lower is better and 1 is perfect. A topic with attempts but
+// no correct answers reports +Inf rather than being dropped, so callers
+// can flag it.
+func CalculateUserTopicEfficiency(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	efficiency := make(map[string]float64, len(counts))
+	for _, c := range counts {
+		if c.Correct == 0 {
+			efficiency[c.Topic] = math.Inf(1)
+			continue
+		}
+		efficiency[c.Topic] = float64(c.Total) / float64(c.Correct)
+	}
+	return efficiency, nil
+}
+
+// CalculateUserTopicMultiPartAccuracy returns a map[topic]accuracy%
+// built from part Scores: each question's score is the mean Score of
+// all its part attempts (so a two-part question half right scores 0.5
+// and counts once, however many parts it has), and a topic's accuracy
+// is the mean of its question scores. A question none of whose attempts
+// carries a Score above 0 is treated as unscored and falls back to its
+// share of IsCorrect attempts.
+func CalculateUserTopicMultiPartAccuracy(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	type Result struct {
+		Topic    string
+		Accuracy float64
+	}
+
+	perQuestion := userAttempts(db, userID).
+		Select(`
+			questions.topic  AS topic,
+			CASE
+				WHEN MAX(question_attempts.score) > 0 THEN AVG(question_attempts.score)
+				ELSE AVG(CASE WHEN question_attempts.is_correct THEN 1.0 ELSE 0.0 END)
+			END * 100.0      AS accuracy
+		`).
+		Group("questions.topic, question_attempts.question_id")
+
+	var results []Result
+	err := db.
+		Table("(?) AS per_question", perQuestion).
+		Select("topic, AVG(accuracy) AS accuracy").
+		Group("topic").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	accuracies := make(map[string]float64, len(results))
+	for _, r := range results {
+		accuracies[r.Topic] = r.Accuracy
+	}
+	return accuracies, nil
+}
+
+// Aggregation strategies accepted by CalculateUserOverallAccuracyStrategy.
+const (
+	// StrategyMicro pools every attempt, so busy topics weigh more.
+	StrategyMicro = "micro"
+	// StrategyMacro averages per-topic accuracies, so every topic
+	// weighs the same.
+	StrategyMacro = "macro"
+)
+
+// CalculateUserOverallAccuracyStrategy returns the user's overall
+// accuracy% aggregated with StrategyMicro or StrategyMacro. The two
+// agree only when every topic has the same number of attempts. It
+// returns ErrInvalidStrategy for any other strategy and ErrNoData if
+// the user has no attempts.
+func CalculateUserOverallAccuracyStrategy(db *gorm.DB, userID uuid.UUID, strategy string) (float64, error) {
+	if strategy != StrategyMicro && strategy != StrategyMacro {
+		return 0, ErrInvalidStrategy
+	}
+
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	if len(counts) == 0 {
+		return 0, ErrNoData
+	}
+
+	if strategy == StrategyMacro {
+		accs := make([]float64, len(counts))
+		for i, c := range counts {
+			accs[i] = c.accuracy()
+		}
+		return mean(accs), nil
+	}
+
+	var pooled topicCount
+	for _, c := range counts {
+		pooled.Total += c.Total
+		pooled.Correct += c.Correct
+	}
+	return pooled.accuracy(), nil
+}
+
+// CalculateUserWeightedTopicScore returns a credit-weighted average of
+// the user's per-topic accuracy%, so important topics move the score
+// more regardless of how often they were practised. Only topics that
+// are both listed in credits and attempted by the user count; unlisted
+// topics carry no weight and listed ones never attempted are skipped,
+// with the remaining credits renormalized. It returns ErrNoData when no
+// credited topic has attempts.
+func CalculateUserWeightedTopicScore(db *gorm.DB, userID uuid.UUID, credits map[string]float64) (float64, error) {
+	if _, err := normalizeWeights(credits); err != nil {
+		return 0, err
+	}
+
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	return weightedAverage(accuracyByTopic(counts), credits)
+}
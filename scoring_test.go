@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserTopicGuessCorrectedAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	mustCreate(t, db, &[]Question{
+		{ID: 1, Topic: "Algebra", OptionCount: 4},
+		{ID: 2, Topic: "Essay"},
+		{ID: 3, Topic: "Geometry", OptionCount: 2},
+	})
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true, false))
+	mustCreate(t, db, outcomes(user, 3, 20, false))
+
+	got, err := CalculateUserTopicGuessCorrectedAccuracy(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{
+		"Algebra":  (0.75 - 0.25) / 0.75 * 100,
+		"Essay":    50,
+		"Geometry": 0,
+	})
+}
+
+func TestCalculateUserTopicAccuracyQuestionCapped(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, false))
+
+	got, err := CalculateUserTopicAccuracyQuestionCapped(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Per question 75% and 0%, not the pooled 3/5.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 37.5})
+}
+
+func TestCalculateUserTopicCompositeScore(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true))
+
+	got, err := CalculateUserTopicCompositeScore(db, user, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Algebra: 100% accuracy, 50% coverage. Geometry untouched.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 87.5, "Geometry": 0})
+
+	if _, err := CalculateUserTopicCompositeScore(db, user, 0, 0); !errors.Is(err, ErrInvalidWeights) {
+		t.Fatalf("got %v, want ErrInvalidWeights", err)
+	}
+}
+
+func TestCalculateUserBrierScore(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := outcomes(user, 1, 0, true, false, true)
+	high, low := 0.8, 0.6
+	attempts[0].PredictedProbability = &high
+	attempts[1].PredictedProbability = &low
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateUserBrierScore(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (0.2² + 0.6²) / 2; the attempt without a prediction is skipped.
+	if !approxEqual(got, 0.2) {
+		t.Fatalf("got %v, want 0.2", got)
+	}
+
+	if _, err := CalculateUserBrierScore(db, uuid.New()); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestApplyCurve(t *testing.T) {
+	accuracies := map[string]float64{"Algebra": 50, "Geometry": 95}
+
+	for _, tc := range []struct {
+		curve CurveSpec
+		want  map[string]float64
+	}{
+		{CurveSpec{Kind: CurveAdditive, Amount: 10}, map[string]float64{"Algebra": 60, "Geometry": 100}},
+		{CurveSpec{Kind: CurveMultiplicative, Amount: 0.5}, map[string]float64{"Algebra": 25, "Geometry": 47.5}},
+	} {
+		got, err := ApplyCurve(accuracies, tc.curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertAccuracies(t, got, tc.want)
+	}
+	// The input is left untouched.
+	assertAccuracies(t, accuracies, map[string]float64{"Algebra": 50, "Geometry": 95})
+
+	if _, err := ApplyCurve(accuracies, CurveSpec{Kind: CurveMultiplicative + 1, Amount: 10}); !errors.Is(err, ErrInvalidCurve) {
+		t.Fatalf("got %v, want ErrInvalidCurve", err)
+	}
+}
+
+func TestCalculateUserConservativeScore(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true, true, true, true, true, true, true, true))
+	mustCreate(t, db, outcomes(user, 2, 20, true))
+
+	got, err := CalculateUserConservativeScore(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// For a perfect record of n the Wilson lower bound is n / (n + z²).
+	z2 := 1.96 * 1.96
+	want := (10*(10/(10+z2)) + 1*(1/(1+z2))) / 11 * 100
+	if !approxEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if _, err := CalculateUserConservativeScore(db, uuid.New()); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestLetterGrade(t *testing.T) {
+	for acc, want := range map[float64]string{100: "A", 90: "A", 89.9: "B", 80: "B", 75: "C", 60: "D", 59.9: "F", 0: "F"} {
+		if got := LetterGrade(acc); got != want {
+			t.Errorf("LetterGrade(%v) = %q, want %q", acc, got, want)
+		}
+	}
+}
+
+func TestCalculateUserGPA(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, true))                     // A
+	mustCreate(t, db, outcomes(user, 2, 10, true, true, true, false)) // C
+	mustCreate(t, db, outcomes(user, 3, 20, false))                   // F
+
+	scale := map[string]float64{"A": 4, "B": 3, "C": 2, "D": 1}
+	got, err := CalculateUserGPA(db, user, scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approxEqual(got, 2) {
+		t.Fatalf("got %v, want 2", got)
+	}
+
+	if _, err := CalculateUserGPA(db, uuid.New(), scale); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestCalculateUserRobustOverallAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus", "Biology")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 3, 20, true, true, true, true, true))
+	mustCreate(t, db, outcomes(user, 4, 30, false, false, false, false, false))
+
+	got, err := CalculateUserRobustOverallAccuracy(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Topic accuracies {0, 80, 80, 100} put Q1 at 60 and Q3 at 85, so
+	// Biology's 0 falls below the 22.5 fence and is dropped.
+	if !approxEqual(got, 1300.0/15) {
+		t.Fatalf("got %v, want %v", got, 1300.0/15)
+	}
+
+	if _, err := CalculateUserRobustOverallAccuracy(db, uuid.New()); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestCalculateUserTopicEfficiency(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, false, true, false, true, true))
+	mustCreate(t, db, outcomes(user, 2, 10, false, false))
+
+	got, err := CalculateUserTopicEfficiency(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !approxEqual(got["Algebra"], 5.0/3) || !math.IsInf(got["Geometry"], 1) {
+		t.Fatalf("got %v, want Algebra 5/3 and Geometry +Inf", got)
+	}
+}
+
+func TestCalculateUserTopicMultiPartAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Geometry")
+	// Question 1 has two scored parts, half right overall.
+	parts := outcomes(user, 1, 0, true, false)
+	parts[0].Score, parts[0].PartIndex = 1, 0
+	parts[1].Score, parts[1].PartIndex = 0, 1
+	mustCreate(t, db, parts)
+	// Question 2 is unscored and falls back to IsCorrect.
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	scored := outcomes(user, 3, 20, true, true)
+	scored[0].Score, scored[1].Score = 0.25, 0.75
+	mustCreate(t, db, scored)
+
+	got, err := CalculateUserTopicMultiPartAccuracy(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 75, "Geometry": 50})
+}
+
+func TestCalculateUserOverallAccuracyStrategy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, false))
+
+	for strategy, want := range map[string]float64{StrategyMicro: 60, StrategyMacro: 37.5} {
+		got, err := CalculateUserOverallAccuracyStrategy(db, user, strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !approxEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", strategy, got, want)
+		}
+	}
+
+	if _, err := CalculateUserOverallAccuracyStrategy(db, user, "median"); !errors.Is(err, ErrInvalidStrategy) {
+		t.Fatalf("got %v, want ErrInvalidStrategy", err)
+	}
+	if _, err := CalculateUserOverallAccuracyStrategy(db, uuid.New(), StrategyMicro); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestCalculateUserWeightedTopicScore(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, results(9, 10)...))
+	mustCreate(t, db, outcomes(user, 2, 20, false))
+	mustCreate(t, db, outcomes(user, 3, 30, true))
+
+	// Calculus is uncredited and Biology unattempted, leaving Algebra
+	// and Geometry at 1:4 whatever their attempt counts.
+	credits := map[string]float64{"Algebra": 1, "Geometry": 4, "Biology": 5}
+	got, err := CalculateUserWeightedTopicScore(db, user, credits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (90*1 + 0*4) / 5.0; !approxEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if _, err := CalculateUserWeightedTopicScore(db, user, map[string]float64{"Biology": 1}); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+	if _, err := CalculateUserWeightedTopicScore(db, user, map[string]float64{"Algebra": -1}); !errors.Is(err, ErrInvalidWeights) {
+		t.Fatalf("got %v, want ErrInvalidWeights", err)
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PriorOutcomeAccuracy splits a topic's accuracy% by how the user did
+// on the attempt immediately before.
+type PriorOutcomeAccuracy struct {
+	AfterCorrect   float64
+	AfterIncorrect float64
+}
+
+// CalculateUserTopicAccuracyByPriorOutcome returns, per topic, the
+// accuracy% of attempts that followed a correct answer and of those
+// that followed an incorrect one. "Previous" means the user's previous
+// attempt on any question, so the very first attempt is not counted.
+// A side with no attempts reports 0.
+func CalculateUserTopicAccuracyByPriorOutcome(db *gorm.DB, userID uuid.UUID) (map[string]PriorOutcomeAccuracy, error) {
+	type Result struct {
+		Topic       string
+		PrevCorrect bool
+		Total       int64
+		Correct     int64
+	}
+
+	ordered := userAttempts(db, userID).
+		Select(`
+			questions.topic                                                     AS topic,
+			question_attempts.is_correct                                        AS is_correct,
+			LAG(question_attempts.is_correct) OVER (ORDER BY ` + attemptOrder + `) AS prev_correct
+		`)
+
+	var results []Result
+	err := db.
+		Table("(?) AS ordered", ordered).
+		Select(`
+			topic,
+			prev_correct,
+			COUNT(*)                                     AS total,
+			SUM(CASE WHEN is_correct THEN 1 ELSE 0 END)  AS correct
+		`).
+		Where("prev_correct IS NOT NULL").
+		Group("topic, prev_correct").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	accuracies := make(map[string]PriorOutcomeAccuracy)
+	for _, r := range results {
+		acc := accuracies[r.Topic]
+		c := topicCount{Topic: r.Topic, Total: r.Total, Correct: r.Correct}
+		if r.PrevCorrect {
+			acc.AfterCorrect = c.accuracy()
+		} else {
+			acc.AfterIncorrect = c.accuracy()
+		}
+		accuracies[r.Topic] = acc
+	}
+	return accuracies, nil
+}
+
+// MostConsistentTopic returns the topic whose running accuracy varies
+// least over the user's attempts, considering only topics with at least
+// minAttempts attempts. Ties go to the alphabetically first topic.
+// It returns ErrNoQualifyingTopic when no topic has enough attempts.
+func MostConsistentTopic(db *gorm.DB, userID uuid.UUID, minAttempts int) (string, error) {
+	outcomes, err := userTopicOutcomes(db, userID)
+	if err != nil {
+		return "", err
+	}
+
+	best, bestVariance := "", 0.0
+	for topic, results := range outcomes {
+		if len(results) < minAttempts || len(results) == 0 {
+			continue
+		}
+		v := variance(runningAccuracy(results))
+		if best == "" || v < bestVariance || (v == bestVariance && topic < best) {
+			best, bestVariance = topic, v
+		}
+	}
+	if best == "" {
+		return "", ErrNoQualifyingTopic
+	}
+	return best, nil
+}
+
+// CalculateUserTopicAccuracyWithWarmup returns a map[topic]accuracy%
+// after discarding the user's first warmup attempts in each topic, so
+// early fumbling while getting oriented doesn't count. Topics with no
+// attempts beyond the warmup are omitted.
+func CalculateUserTopicAccuracyWithWarmup(db *gorm.DB, userID uuid.UUID, warmup int) (map[string]float64, error) {
+	if warmup < 0 {
+		return nil, ErrInvalidWarmup
+	}
+
+	ordered := userAttempts(db, userID).
+		Select(`
+			questions.topic               AS topic,
+			question_attempts.is_correct  AS is_correct,
+			ROW_NUMBER() OVER (
+				PARTITION BY questions.topic
+				ORDER BY ` + attemptOrder + `
+			)                             AS rn
+		`)
+
+	var counts []topicCount
+	err := db.
+		Table("(?) AS ordered", ordered).
+		Select(`
+			topic,
+			COUNT(*)                                     AS total,
+			SUM(CASE WHEN is_correct THEN 1 ELSE 0 END)  AS correct
+		`).
+		Where("rn > ?", warmup).
+		Group("topic").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateRetryUplift returns how many percentage points retrying adds
+// to the user's accuracy: the share of attempted questions eventually
+// answered correctly minus the share answered correctly on the first
+// try. Equivalently, it is the share of all questions that were missed
+// first and later recovered. It returns ErrNoData if the user has no
+// attempts.
+func CalculateRetryUplift(db *gorm.DB, userID uuid.UUID) (float64, error) {
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, ErrNoData
+	}
+
+	firstCorrect := make(map[uint]bool)
+	everCorrect := make(map[uint]bool)
+	for _, r := range records {
+		if _, seen := firstCorrect[r.QuestionID]; !seen {
+			firstCorrect[r.QuestionID] = r.IsCorrect
+		}
+		everCorrect[r.QuestionID] = everCorrect[r.QuestionID] || r.IsCorrect
+	}
+
+	recovered := 0
+	for q, first := range firstCorrect {
+		if !first && everCorrect[q] {
+			recovered++
+		}
+	}
+	return float64(recovered) * 100 / float64(len(firstCorrect)), nil
+}
+
+// AttemptImpact is how far one attempt moved a topic's running
+// accuracy, in percentage points.
+type AttemptImpact struct {
+	AttemptID     uint
+	DeltaAccuracy float64
+}
+
+// CalculateAttemptMarginalImpact returns, for each of the user's
+// attempts in topic in order, the change it caused in their running
+// accuracy%. The running accuracy starts from 0 before the first
+// attempt, so the deltas sum to the topic's current accuracy.
+func CalculateAttemptMarginalImpact(db *gorm.DB, userID uuid.UUID, topic string) ([]AttemptImpact, error) {
+	records, err := userAttemptRecords(userAttempts(db, userID).Where("questions.topic = ?", topic))
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]bool, len(records))
+	for i, r := range records {
+		outcomes[i] = r.IsCorrect
+	}
+
+	impacts := make([]AttemptImpact, len(records))
+	prev := 0.0
+	for i, acc := range runningAccuracy(outcomes) {
+		impacts[i] = AttemptImpact{AttemptID: records[i].ID, DeltaAccuracy: acc - prev}
+		prev = acc
+	}
+	return impacts, nil
+}
+
+// CalculateUserTopicTimedFirstAttemptAccuracy returns a
+// map[topic]accuracy% for exam-style scoring: only the user's first
+// attempt at each question counts, and it counts as correct only if it
+// was both right and answered within limitMs milliseconds. Slow right
+// answers are scored as wrong.
+func CalculateUserTopicTimedFirstAttemptAccuracy(db *gorm.DB, userID uuid.UUID, limitMs int64) (map[string]float64, error) {
+	if limitMs <= 0 {
+		return nil, ErrInvalidDuration
+	}
+
+	firsts := userAttempts(db, userID).
+		Select(`
+			questions.topic                  AS topic,
+			question_attempts.is_correct     AS is_correct,
+			question_attempts.time_spent_ms  AS time_spent_ms,
+			ROW_NUMBER() OVER (
+				PARTITION BY question_attempts.question_id
+				ORDER BY ` + attemptOrder + `
+			)                                AS rn
+		`)
+
+	var counts []topicCount
+	err := db.
+		Table("(?) AS firsts", firsts).
+		Select(`
+			topic,
+			COUNT(*)                                                             AS total,
+			SUM(CASE WHEN is_correct AND time_spent_ms <= ? THEN 1 ELSE 0 END)  AS correct
+		`, limitMs).
+		Where("rn = 1").
+		Group("topic").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// CalculateAttemptsSinceImprovement returns, per topic, how many of the
+// user's attempts have passed since their running accuracy% last went
+// up. The running accuracy starts from 0, so a topic where every
+// attempt was wrong reports its full attempt count. High values flag a
+// user who is stuck.
+func CalculateAttemptsSinceImprovement(db *gorm.DB, userID uuid.UUID) (map[string]int, error) {
+	outcomes, err := userTopicOutcomes(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	since := make(map[string]int, len(outcomes))
+	for topic, results := range outcomes {
+		stale, prev := 0, 0.0
+		for _, acc := range runningAccuracy(results) {
+			if acc > prev {
+				stale = 0
+			} else {
+				stale++
+			}
+			prev = acc
+		}
+		since[topic] = stale
+	}
+	return since, nil
+}
+
+// CalculateAccuracyByGapBucket returns a map[gapBand]accuracy% over the
+// user's repeat attempts, bucketed by how many days had passed since
+// their previous attempt at the same question; first attempts are not
+// counted. Like complexity bands, bucketsDays are edges with each band
+// including its lower edge only: edges [1, 7] give "<1d", "[1d,7d)" and
+// ">=7d". Plotted in order this traces the user's forgetting curve.
+func CalculateAccuracyByGapBucket(db *gorm.DB, userID uuid.UUID, bucketsDays []int) (map[string]float64, error) {
+	if err := validateBands(bucketsDays); err != nil {
+		return nil, err
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	lastSeen := make(map[uint]time.Time)
+	var repeats []attemptRecord
+	for _, r := range records {
+		if prev, ok := lastSeen[r.QuestionID]; ok {
+			days := r.CreatedAt.Sub(prev).Hours() / 24
+			r.Topic = gapBand(bucketsDays, days)
+			repeats = append(repeats, r)
+		}
+		lastSeen[r.QuestionID] = r.CreatedAt
+	}
+	return accuracyByTopic(countByTopic(repeats)), nil
+}
+
+// gapBand labels the band of edges that a gap of days falls in.
+func gapBand(edges []int, days float64) string {
+	i := sort.Search(len(edges), func(i int) bool { return float64(edges[i]) > days })
+	return bandLabel(edges, i, "d")
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserTopicAccuracyByPriorOutcome(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	// The previous attempt is the user's previous one on any topic.
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 2, false, 1), // after correct
+		attempt(user, 1, true, 2),  // after incorrect
+		attempt(user, 1, false, 3), // after correct
+		attempt(user, 2, true, 4),  // after incorrect
+		attempt(user, 1, true, 5),  // after correct
+	})
+
+	got, err := CalculateUserTopicAccuracyByPriorOutcome(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]PriorOutcomeAccuracy{
+		"Algebra":  {AfterCorrect: 50, AfterIncorrect: 100},
+		"Geometry": {AfterCorrect: 0, AfterIncorrect: 100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for topic, w := range want {
+		if g := got[topic]; !approxEqual(g.AfterCorrect, w.AfterCorrect) || !approxEqual(g.AfterIncorrect, w.AfterIncorrect) {
+			t.Fatalf("%s: got %+v, want %+v", topic, g, w)
+		}
+	}
+}
+
+func TestMostConsistentTopic(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Biology", "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, true, true, true))
+	mustCreate(t, db, outcomes(user, 2, 10, false, false, false))
+	mustCreate(t, db, outcomes(user, 3, 20, true, false, true))
+	mustCreate(t, db, outcomes(user, 4, 30, true))
+
+	// Biology and Algebra both hold steady; the tie goes to Algebra.
+	got, err := MostConsistentTopic(db, user, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Algebra" {
+		t.Fatalf("got %q, want Algebra", got)
+	}
+
+	if _, err := MostConsistentTopic(db, user, 10); !errors.Is(err, ErrNoQualifyingTopic) {
+		t.Fatalf("got %v, want ErrNoQualifyingTopic", err)
+	}
+}
+
+func TestCalculateUserTopicAccuracyWithWarmup(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, false, false, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true, true))
+
+	got, err := CalculateUserTopicAccuracyWithWarmup(db, user, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Geometry has nothing beyond its warmup.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 200.0 / 3})
+
+	if _, err := CalculateUserTopicAccuracyWithWarmup(db, user, -1); !errors.Is(err, ErrInvalidWarmup) {
+		t.Fatalf("got %v, want ErrInvalidWarmup", err)
+	}
+}
+
+func TestCalculateRetryUplift(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Geometry", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, false, true)) // recovered
+	mustCreate(t, db, outcomes(user, 2, 10, true, false))
+	mustCreate(t, db, outcomes(user, 3, 20, false, false))
+	mustCreate(t, db, outcomes(user, 4, 30, false))
+
+	got, err := CalculateRetryUplift(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approxEqual(got, 25) {
+		t.Fatalf("got %v, want 25", got)
+	}
+
+	if _, err := CalculateRetryUplift(db, uuid.New()); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestCalculateAttemptMarginalImpact(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	attempts := []QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 2, false, 1),
+		attempt(user, 1, false, 2),
+		attempt(user, 1, true, 3),
+	}
+	for i := range attempts {
+		attempts[i].ID = uint(i + 1)
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateAttemptMarginalImpact(db, user, "Algebra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Running accuracy 100, 50, 66.7; the deltas sum to the last.
+	want := []AttemptImpact{{1, 100}, {3, -50}, {4, 50.0 / 3}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, w := range want {
+		if got[i].AttemptID != w.AttemptID || !approxEqual(got[i].DeltaAccuracy, w.DeltaAccuracy) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCalculateUserTopicTimedFirstAttemptAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Algebra", "Algebra")
+	attempts := []QuestionAttempt{
+		attempt(user, 1, true, 0),  // fast and right
+		attempt(user, 2, true, 1),  // right but slow
+		attempt(user, 2, true, 2),  // retry, ignored
+		attempt(user, 3, false, 3), // fast but wrong
+	}
+	for i, ms := range []int64{5000, 40000, 1000, 2000} {
+		attempts[i].TimeSpentMs = ms
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateUserTopicTimedFirstAttemptAccuracy(db, user, 30000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 100.0 / 3})
+
+	if _, err := CalculateUserTopicTimedFirstAttemptAccuracy(db, user, 0); !errors.Is(err, ErrInvalidDuration) {
+		t.Fatalf("got %v, want ErrInvalidDuration", err)
+	}
+}
+
+func TestCalculateAttemptsSinceImprovement(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	// Running accuracy 0, 50, 33, 25: last rose at the second attempt.
+	mustCreate(t, db, outcomes(user, 1, 0, false, true, false, false))
+	mustCreate(t, db, outcomes(user, 2, 10, false, false, false))
+	mustCreate(t, db, outcomes(user, 3, 20, false, true))
+
+	got, err := CalculateAttemptsSinceImprovement(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"Algebra": 2, "Geometry": 3, "Calculus": 0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateAccuracyByGapBucket(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	const day = 24 * 60
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, false, 0),     // first, not counted
+		attempt(user, 1, true, 60),     // gap under a day
+		attempt(user, 1, true, 60+day), // exactly one day
+		attempt(user, 2, true, 0),
+		attempt(user, 2, false, 7*day), // exactly seven days
+	})
+
+	got, err := CalculateAccuracyByGapBucket(db, user, []int{1, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"<1d": 100, "[1d,7d)": 100, ">=7d": 0})
+
+	if _, err := CalculateAccuracyByGapBucket(db, user, nil); !errors.Is(err, ErrInvalidBands) {
+		t.Fatalf("got %v, want ErrInvalidBands", err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionImpact returns, per topic practised in the session, how many
+// percentage points the user's cumulative accuracy moved: accuracy over
+// everything up to the session's last attempt minus accuracy over
+// everything before its first. Topics with no attempts before the
+// session have no baseline and are omitted.
+func SessionImpact(db *gorm.DB, userID, sessionID uuid.UUID) (map[string]float64, error) {
+	start, end, err := sessionBounds(db, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.created_at < ?", start),
+	)
+	if err != nil {
+		return nil, err
+	}
+	after, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.created_at <= ?", end),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return accuracyDeltas(before, after), nil
+}
+
+// sessionBounds returns the times of the first and last attempt the
+// user made in a session, or ErrNoData if there are none. It orders by
+// created_at alone rather than attemptOrder: only the timestamps are
+// returned, and tied attempts share the same one.
+func sessionBounds(db *gorm.DB, userID, sessionID uuid.UUID) (start, end time.Time, err error) {
+	var first, last []time.Time
+	scoped := func() *gorm.DB {
+		return db.
+			Model(&QuestionAttempt{}).
+			Where("user_id = ? AND session_id = ?", userID, sessionID).
+			Limit(1)
+	}
+	if err = scoped().Order("created_at").Pluck("created_at", &first).Error; err != nil {
+		return start, end, err
+	}
+	if len(first) == 0 {
+		return start, end, ErrNoData
+	}
+	if err = scoped().Order("created_at DESC").Pluck("created_at", &last).Error; err != nil {
+		return start, end, err
+	}
+	return first[0], last[0], nil
+}
+
+// CalculateTopicAccuracyForSessions returns a map[topic]accuracy% over
+// the user's attempts in the listed sessions only. An empty list
+// returns an empty map.
+func CalculateTopicAccuracyForSessions(db *gorm.DB, userID uuid.UUID, sessionIDs []uuid.UUID) (map[string]float64, error) {
+	if len(sessionIDs) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.session_id IN ?", sessionIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
+
+// SessionContribution is one session's share of a user's history.
+type SessionContribution struct {
+	SessionID uuid.UUID
+	Attempts  int64
+	Accuracy  float64
+}
+
+// CalculateSessionContributions returns the attempt count and accuracy%
+// of each of the user's sessions, ordered by when each session started.
+// Attempts recorded without a session are left out.
+func CalculateSessionContributions(db *gorm.DB, userID uuid.UUID) ([]SessionContribution, error) {
+	type Result struct {
+		SessionID uuid.UUID
+		Total     int64
+		Correct   int64
+	}
+
+	var results []Result
+	err := db.
+		Model(&QuestionAttempt{}).
+		Select(`
+			session_id,
+			COUNT(*)                                     AS total,
+			SUM(CASE WHEN is_correct THEN 1 ELSE 0 END)  AS correct
+		`).
+		Where("user_id = ? AND session_id <> ?", userID, uuid.Nil).
+		Group("session_id").
+		Order("MIN(created_at), MIN(id)").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	contributions := make([]SessionContribution, len(results))
+	for i, r := range results {
+		contributions[i] = SessionContribution{
+			SessionID: r.SessionID,
+			Attempts:  r.Total,
+			Accuracy:  topicCount{Total: r.Total, Correct: r.Correct}.accuracy(),
+		}
+	}
+	return contributions, nil
+}
+
+// CalculateUserSessionConsistency returns a 0–1 score for how steady the
+// user's accuracy is from session to session: 1/(1+CV), where CV is the
+// coefficient of variation (standard deviation over mean) of per-session
+// accuracies. Identical sessions score 1 and the score falls towards 0
+// as results swing. It returns ErrNoData without any sessions and
+// ErrInsufficientData with just one.
+func CalculateUserSessionConsistency(db *gorm.DB, userID uuid.UUID) (float64, error) {
+	sessions, err := CalculateSessionContributions(db, userID)
+	if err != nil {
+		return 0, err
+	}
+	switch len(sessions) {
+	case 0:
+		return 0, ErrNoData
+	case 1:
+		return 0, ErrInsufficientData
+	}
+
+	accs := make([]float64, len(sessions))
+	for i, s := range sessions {
+		accs[i] = s.Accuracy
+	}
+	m, sd := mean(accs), math.Sqrt(variance(accs))
+	if sd == 0 {
+		return 1, nil
+	}
+	return 1 / (1 + sd/m), nil
+}
+
+// CalculateUserTopicProctoredAccuracy returns a map[topic]accuracy%
+// over attempts made in proctored sessions only, for grading.
+// Unproctored attempts still count everywhere else.
+func CalculateUserTopicProctoredAccuracy(db *gorm.DB, userID uuid.UUID) (map[string]float64, error) {
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).
+			Joins("JOIN sessions ON sessions.id = question_attempts.session_id").
+			Where("sessions.proctored = ?", true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accuracyByTopic(counts), nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionImpact(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	session := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+
+	attempts := outcomes(user, 1, 0, true, false)
+	inSession := append(outcomes(user, 1, 10, true, true), attempt(user, 2, true, 12))
+	for i := range inSession {
+		inSession[i].SessionID = session
+	}
+	mustCreate(t, db, append(attempts, inSession...))
+
+	got, err := SessionImpact(db, user, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Algebra goes from 1/2 to 3/4; Geometry has no baseline.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 25})
+
+	if _, err := SessionImpact(db, user, uuid.New()); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+}
+
+func TestCalculateTopicAccuracyForSessions(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	s1, s2, s3 := uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	attempts := []QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 1, false, 1),
+		attempt(user, 2, true, 2),
+		attempt(user, 2, false, 3),
+	}
+	for i, s := range []uuid.UUID{s1, s2, s2, s3} {
+		attempts[i].SessionID = s
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateTopicAccuracyForSessions(db, user, []uuid.UUID{s1, s2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50, "Geometry": 100})
+
+	got, err = CalculateTopicAccuracyForSessions(db, user, nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("got %v, %v; want an empty map", got, err)
+	}
+}
+
+func TestCalculateSessionContributions(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	late, early := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	attempts := outcomes(user, 1, 0, true, false, true, true)
+	for i, s := range []uuid.UUID{late, early, late, uuid.Nil} {
+		attempts[i].SessionID = s
+	}
+	attempts[1].CreatedAt = testEpoch.Add(-time.Hour)
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateSessionContributions(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SessionContribution{
+		{SessionID: early, Attempts: 1, Accuracy: 0},
+		{SessionID: late, Attempts: 2, Accuracy: 100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCalculateUserSessionConsistency(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	s1, s2 := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	if _, err := CalculateUserSessionConsistency(db, user); !errors.Is(err, ErrNoData) {
+		t.Fatalf("got %v, want ErrNoData", err)
+	}
+
+	first := outcomes(user, 1, 0, true, false)
+	for i := range first {
+		first[i].SessionID = s1
+	}
+	mustCreate(t, db, first)
+	if _, err := CalculateUserSessionConsistency(db, user); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("got %v, want ErrInsufficientData", err)
+	}
+
+	second := outcomes(user, 1, 10, true)
+	second[0].SessionID = s2
+	mustCreate(t, db, second)
+	got, err := CalculateUserSessionConsistency(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sessions at 50% and 100%: CV = 25/75.
+	if !approxEqual(got, 0.75) {
+		t.Fatalf("got %v, want 0.75", got)
+	}
+}
+
+func TestCalculateUserTopicProctoredAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	proctored, open := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	mustCreate(t, db, &[]Session{
+		{ID: proctored, UserID: user, Proctored: true},
+		{ID: open, UserID: user},
+	})
+	attempts := outcomes(user, 1, 0, true, false, true, true)
+	for i, s := range []uuid.UUID{proctored, proctored, open, uuid.Nil} {
+		attempts[i].SessionID = s
+	}
+	mustCreate(t, db, attempts)
+
+	got, err := CalculateUserTopicProctoredAccuracy(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 50})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// minSignificanceAttempts is the fewest attempts each side of a
+// significance test needs for the normal approximation to be usable.
+const minSignificanceAttempts = 10
+
+// CompareTopicsSignificance tests whether the user's accuracy in topicA
+// genuinely differs from topicB, returning the two-sided p-value of a
+// two-proportion z-test; small values mean the gap is unlikely to be
+// noise. It returns ErrInsufficientData unless both topics have at
+// least minSignificanceAttempts attempts.
+func CompareTopicsSignificance(db *gorm.DB, userID uuid.UUID, topicA, topicB string) (pValue float64, err error) {
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).Where("questions.topic IN ?", []string{topicA, topicB}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	byTopic := make(map[string]topicCount, len(counts))
+	for _, c := range counts {
+		byTopic[c.Topic] = c
+	}
+	a, b := byTopic[topicA], byTopic[topicB]
+	if a.Total < minSignificanceAttempts || b.Total < minSignificanceAttempts {
+		return 0, ErrInsufficientData
+	}
+	return twoProportionPValue(a.Correct, a.Total, b.Correct, b.Total), nil
+}
+
+// CalculateInterventionLift compares a treatment cohort with a control
+// cohort in topic. lift is treatment accuracy% minus control accuracy%,
+// pooling each cohort's attempts, and pValue is the two-sided p-value
+// of a two-proportion z-test on those pools. It returns
+// ErrInsufficientData unless each cohort has at least
+// minSignificanceAttempts attempts in the topic.
+func CalculateInterventionLift(db *gorm.DB, treatmentUsers, controlUsers []uuid.UUID, topic string) (lift float64, pValue float64, err error) {
+	treatment, err := cohortTopicCount(db, treatmentUsers, topic)
+	if err != nil {
+		return 0, 0, err
+	}
+	control, err := cohortTopicCount(db, controlUsers, topic)
+	if err != nil {
+		return 0, 0, err
+	}
+	if treatment.Total < minSignificanceAttempts || control.Total < minSignificanceAttempts {
+		return 0, 0, ErrInsufficientData
+	}
+
+	lift = treatment.accuracy() - control.accuracy()
+	pValue = twoProportionPValue(treatment.Correct, treatment.Total, control.Correct, control.Total)
+	return lift, pValue, nil
+}
+
+// cohortTopicCount pools the listed users' attempts in topic.
+func cohortTopicCount(db *gorm.DB, userIDs []uuid.UUID, topic string) (topicCount, error) {
+	userIDs = uniqueUsers(userIDs)
+	if len(userIDs) == 0 {
+		return topicCount{Topic: topic}, nil
+	}
+
+	counts, err := scanTopicCounts(cohortAttempts(db, userIDs).Where("questions.topic = ?", topic))
+	if err != nil || len(counts) == 0 {
+		return topicCount{Topic: topic}, err
+	}
+	return counts[0], nil
+}
+
+// CalculateVolumeAccuracyCorrelation returns the Pearson correlation,
+// for a single user across their topics, between how many attempts a
+// topic has and its accuracy%. A positive value suggests more practice
+// goes with better results for this user; it says nothing about other
+// users. It returns ErrInsufficientData with fewer than two topics and
+// 0 when either measure is the same for every topic.
+func CalculateVolumeAccuracyCorrelation(db *gorm.DB, userID uuid.UUID) (float64, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return 0, err
+	}
+	if len(counts) < 2 {
+		return 0, ErrInsufficientData
+	}
+
+	volumes := make([]float64, len(counts))
+	accs := make([]float64, len(counts))
+	for i, c := range counts {
+		volumes[i] = float64(c.Total)
+		accs[i] = c.accuracy()
+	}
+	return pearson(volumes, accs), nil
+}
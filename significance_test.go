@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCompareTopicsSignificance(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, results(18, 20)...))
+	mustCreate(t, db, outcomes(user, 2, 100, results(10, 20)...))
+	mustCreate(t, db, outcomes(user, 3, 200, results(5, 9)...))
+
+	got, err := CompareTopicsSignificance(db, user, "Algebra", "Geometry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 90% vs 50% over 20 each: pooled 0.7, z ≈ 2.7603.
+	if math.Abs(got-0.0057755) > 1e-6 {
+		t.Fatalf("got %v, want ≈0.0057755", got)
+	}
+
+	if _, err := CompareTopicsSignificance(db, user, "Algebra", "Calculus"); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("got %v, want ErrInsufficientData", err)
+	}
+}
+
+func TestCalculateInterventionLift(t *testing.T) {
+	db := newTestDB(t)
+	t1, t2, c1, c2 := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra")
+	mustCreate(t, db, outcomes(t1, 1, 0, results(8, 10)...))
+	mustCreate(t, db, outcomes(t2, 1, 100, results(10, 10)...))
+	mustCreate(t, db, outcomes(c1, 1, 200, results(10, 20)...))
+	mustCreate(t, db, outcomes(c2, 1, 300, results(5, 9)...))
+
+	// Pools of 18/20 and 10/20, as in the topic comparison.
+	lift, p, err := CalculateInterventionLift(db, []uuid.UUID{t1, t2, t1}, []uuid.UUID{c1}, "Algebra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approxEqual(lift, 40) || math.Abs(p-0.0057755) > 1e-6 {
+		t.Fatalf("got lift %v p %v, want 40 and ≈0.0057755", lift, p)
+	}
+
+	if _, _, err := CalculateInterventionLift(db, []uuid.UUID{t1}, []uuid.UUID{c2}, "Algebra"); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("got %v, want ErrInsufficientData", err)
+	}
+}
+
+func TestCalculateVolumeAccuracyCorrelation(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	mustCreate(t, db, outcomes(user, 1, 0, results(0, 1)...))
+	if _, err := CalculateVolumeAccuracyCorrelation(db, user); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("got %v, want ErrInsufficientData", err)
+	}
+
+	mustCreate(t, db, outcomes(user, 2, 10, results(1, 2)...))
+	mustCreate(t, db, outcomes(user, 3, 20, results(3, 4)...))
+	got, err := CalculateVolumeAccuracyCorrelation(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Volumes {1, 2, 4} against accuracies {0, 50, 75}.
+	if !approxEqual(got, 13.0/14) {
+		t.Fatalf("got %v, want 13/14", got)
+	}
+}
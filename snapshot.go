@@ -0,0 +1,108 @@
+package main
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TopicAccuracySnapshot is a user's stored per-topic accuracy as of the
+// end of Date, which is always midnight UTC.
+type TopicAccuracySnapshot struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Topic     string    `gorm:"size:100;primaryKey"`
+	Date      time.Time `gorm:"primaryKey"`
+	Total     int64
+	Correct   int64
+	Accuracy  float64
+	CreatedAt time.Time
+}
+
+// ScheduleDailySnapshot records the user's per-topic accuracy as of the
+// end of at's day (UTC) in TopicAccuracySnapshot. It is idempotent per
+// day: running it again for the same day overwrites that day's rows
+// instead of adding new ones, so a retried or repeated job is harmless.
+func ScheduleDailySnapshot(db *gorm.DB, userID uuid.UUID, at time.Time) error {
+	day, _ := bucketStart(at, BucketDay)
+
+	counts, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.created_at < ?", day.AddDate(0, 0, 1)),
+	)
+	if err != nil {
+		return err
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	rows := make([]TopicAccuracySnapshot, len(counts))
+	for i, c := range counts {
+		rows[i] = TopicAccuracySnapshot{
+			UserID:   userID,
+			Topic:    c.Topic,
+			Date:     day,
+			Total:    c.Total,
+			Correct:  c.Correct,
+			Accuracy: c.accuracy(),
+		}
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error
+}
+
+// loadSnapshot returns the user's most recent snapshot whose day had
+// ended by at, keyed by topic, or ErrSnapshotNotFound if there is none.
+// A snapshot covers its whole day, so one dated on at's own day would
+// count attempts made after at. at is compared in UTC, matching the
+// stored dates.
+func loadSnapshot(db *gorm.DB, userID uuid.UUID, at time.Time) (map[string]TopicAccuracySnapshot, error) {
+	var dates []time.Time
+	err := db.
+		Model(&TopicAccuracySnapshot{}).
+		Where("user_id = ? AND date <= ?", userID, at.UTC().AddDate(0, 0, -1)).
+		Order("date DESC").
+		Limit(1).
+		Pluck("date", &dates).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(dates) == 0 {
+		return nil, ErrSnapshotNotFound
+	}
+
+	var rows []TopicAccuracySnapshot
+	if err := db.Where("user_id = ? AND date = ?", userID, dates[0]).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]TopicAccuracySnapshot, len(rows))
+	for _, r := range rows {
+		snapshot[r.Topic] = r
+	}
+	return snapshot, nil
+}
+
+// CompareStoredSnapshots returns the per-topic change in accuracy
+// (percentage points) between the snapshots in effect at fromTime and
+// at toTime, each being the latest whose day had ended by that time.
+// Only topics present in both snapshots are compared. It returns
+// ErrSnapshotNotFound if either time comes before the end of every
+// snapshot's day.
+func CompareStoredSnapshots(db *gorm.DB, userID uuid.UUID, fromTime, toTime time.Time) (map[string]float64, error) {
+	from, err := loadSnapshot(db, userID, fromTime)
+	if err != nil {
+		return nil, err
+	}
+	to, err := loadSnapshot(db, userID, toTime)
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := make(map[string]float64)
+	for topic, t := range to {
+		if f, ok := from[topic]; ok {
+			deltas[topic] = t.Accuracy - f.Accuracy
+		}
+	}
+	return deltas, nil
+}
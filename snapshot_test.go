@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCompareStoredSnapshots(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan3 := jan1.AddDate(0, 0, 2)
+	mustCreate(t, db, &[]TopicAccuracySnapshot{
+		{UserID: user, Topic: "Algebra", Date: jan1, Total: 2, Correct: 1, Accuracy: 50},
+		{UserID: user, Topic: "Geometry", Date: jan1, Total: 1, Correct: 1, Accuracy: 100},
+		{UserID: user, Topic: "Algebra", Date: jan3, Total: 4, Correct: 3, Accuracy: 75},
+		{UserID: user, Topic: "Calculus", Date: jan3, Total: 1, Correct: 0, Accuracy: 0},
+	})
+
+	// Each snapshot takes effect at the midnight that ends its day;
+	// 19:00 on 3 January at UTC-5 is that midnight for the 3 January one.
+	from := jan1.AddDate(0, 0, 1)
+	to := time.Date(2024, 1, 3, 19, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+	got, err := CompareStoredSnapshots(db, user, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only Algebra is in both snapshots.
+	assertAccuracies(t, got, map[string]float64{"Algebra": 25})
+
+	// A second before midnight the 3 January snapshot is not yet in
+	// effect, so both ends load the 1 January one.
+	got, err = CompareStoredSnapshots(db, user, from, to.Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 0, "Geometry": 0})
+
+	if _, err := CompareStoredSnapshots(db, user, from.Add(-time.Second), to); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Fatalf("got %v, want ErrSnapshotNotFound", err)
+	}
+}
+
+func TestScheduleDailySnapshot(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 3*24*60, true)) // after the day
+
+	// 22:00 on 1 January at UTC-5 is 2 January in UTC.
+	at := time.Date(2024, 1, 1, 22, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+	for i := 0; i < 2; i++ {
+		if err := ScheduleDailySnapshot(db, user, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustCreate(t, db, outcomes(user, 1, 60, true))
+	if err := ScheduleDailySnapshot(db, user, at); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []TopicAccuracySnapshot
+	if err := db.Where("user_id = ?", user).Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want one per topic practised by the day", len(rows))
+	}
+	r := rows[0]
+	if r.Topic != "Algebra" || !r.Date.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) || r.Total != 3 || !approxEqual(r.Accuracy, 200.0/3) {
+		t.Fatalf("got %+v, want the rerun's Algebra totals dated 2 January", r)
+	}
+
+	if _, err := loadSnapshot(db, user, at); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Fatalf("got %v, want ErrSnapshotNotFound before 2 January has ended", err)
+	}
+	snapshot, err := loadSnapshot(db, user, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot) != 1 || snapshot["Algebra"].Total != 3 {
+		t.Fatalf("loaded %+v, want the 2 January snapshot", snapshot)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// wilsonZ is the z-score for the 95% intervals used by default.
+const wilsonZ = 1.96
+
+// mean returns the arithmetic mean of xs, or 0 when xs is empty.
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// slope returns the least-squares slope of ys against xs, or 0 when xs
+// is constant.
+func slope(xs, ys []float64) float64 {
+	mx, my := mean(xs), mean(ys)
+	var sxy, sxx float64
+	for i := range xs {
+		sxy += (xs[i] - mx) * (ys[i] - my)
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if sxx == 0 {
+		return 0
+	}
+	return sxy / sxx
+}
+
+// variance returns the population variance of xs, or 0 when xs is empty.
+func variance(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	m := mean(xs)
+	var sum float64
+	for _, x := range xs {
+		sum += (x - m) * (x - m)
+	}
+	return sum / float64(len(xs))
+}
+
+// normalizeWeights scales weights so they sum to 1. It returns
+// ErrInvalidWeights if any weight is negative or they sum to 0.
+func normalizeWeights(weights map[string]float64) (map[string]float64, error) {
+	var total float64
+	for _, w := range weights {
+		if w < 0 {
+			return nil, ErrInvalidWeights
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, ErrInvalidWeights
+	}
+
+	normalized := make(map[string]float64, len(weights))
+	for k, w := range weights {
+		normalized[k] = w / total
+	}
+	return normalized, nil
+}
+
+// wilsonInterval returns the Wilson score interval, as 0–1 fractions,
+// for correct successes out of total at the given z-score. It returns
+// (0, 1) when total is 0.
+func wilsonInterval(correct, total int64, z float64) (low, high float64) {
+	if total == 0 {
+		return 0, 1
+	}
+	n := float64(total)
+	p := float64(correct) / n
+	z2 := z * z
+	center := p + z2/(2*n)
+	margin := z * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	denom := 1 + z2/n
+	return (center - margin) / denom, (center + margin) / denom
+}
+
+// percentileRank returns where x falls among xs as a percentage: the
+// share of values below x plus half the share equal to it. It returns 0
+// when xs is empty.
+func percentileRank(xs []float64, x float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var below, equal int
+	for _, v := range xs {
+		switch {
+		case v < x:
+			below++
+		case v == x:
+			equal++
+		}
+	}
+	return (float64(below) + float64(equal)/2) * 100 / float64(len(xs))
+}
+
+// quantile returns the q-th quantile (0–1) of xs using linear
+// interpolation between closest ranks. xs is not modified. It returns 0
+// when xs is empty.
+func quantile(xs []float64, q float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// median returns the middle value of xs, or 0 when xs is empty.
+func median(xs []float64) float64 {
+	return quantile(xs, 0.5)
+}
+
+// twoProportionPValue returns the two-sided p-value of a pooled
+// two-proportion z-test comparing c1/n1 with c2/n2. It returns 1 when
+// the pooled proportion is 0 or 1, since the samples are then
+// indistinguishable.
+func twoProportionPValue(c1, n1, c2, n2 int64) float64 {
+	p1, p2 := float64(c1)/float64(n1), float64(c2)/float64(n2)
+	pooled := float64(c1+c2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 1
+	}
+	z := (p1 - p2) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// pearson returns the Pearson correlation of xs and ys, which must have
+// the same length. It returns 0 when either series is constant, as the
+// correlation is then undefined.
+func pearson(xs, ys []float64) float64 {
+	mx, my := mean(xs), mean(ys)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// weightedAverage averages values by weights over the keys present in
+// both with a positive weight, renormalizing the weights over those
+// keys. It returns ErrNoData when no key qualifies.
+func weightedAverage(values, weights map[string]float64) (float64, error) {
+	present := make(map[string]float64)
+	for k, w := range weights {
+		if _, ok := values[k]; ok && w > 0 {
+			present[k] = w
+		}
+	}
+	if len(present) == 0 {
+		return 0, ErrNoData
+	}
+	normalized, err := normalizeWeights(present)
+	if err != nil {
+		return 0, err
+	}
+
+	var avg float64
+	for k, w := range normalized {
+		avg += w * values[k]
+	}
+	return avg, nil
+}
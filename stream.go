@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserTopicRow is one user's aggregated result for one topic.
+type UserTopicRow struct {
+	UserID   uuid.UUID
+	Topic    string
+	Total    int64
+	Correct  int64
+	Accuracy float64
+}
+
+// StreamUsersTopicAccuracy sends one UserTopicRow per (user, topic) to
+// out as rows are read from the database, instead of collecting them.
+//
+// StreamUsersTopicAccuracy owns out and always closes it before
+// returning, so consumers can simply range over the channel. If ctx is
+// cancelled it stops sending and returns ctx.Err().
+func StreamUsersTopicAccuracy(ctx context.Context, db *gorm.DB, userIDs []uuid.UUID, out chan<- UserTopicRow) error {
+	defer close(out)
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	rows, err := selectUserTopicCounts(cohortAttempts(db.WithContext(ctx), userIDs)).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row UserTopicRow
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		row.Accuracy = topicCount{Total: row.Total, Correct: row.Correct}.accuracy()
+
+		select {
+		case out <- row:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestStreamUsersTopicAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	u1, u2 := uuid.New(), uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(u1, 1, 0, true, false))
+	mustCreate(t, db, outcomes(u1, 2, 10, true))
+	mustCreate(t, db, outcomes(u2, 1, 20, false))
+
+	out := make(chan UserTopicRow)
+	errc := make(chan error, 1)
+	go func() { errc <- StreamUsersTopicAccuracy(context.Background(), db, []uuid.UUID{u1, u2}, out) }()
+
+	got := make(map[uuid.UUID]map[string]float64)
+	for row := range out {
+		if got[row.UserID] == nil {
+			got[row.UserID] = make(map[string]float64)
+		}
+		got[row.UserID][row.Topic] = row.Accuracy
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got[u1], map[string]float64{"Algebra": 50, "Geometry": 100})
+	assertAccuracies(t, got[u2], map[string]float64{"Algebra": 0})
+}
+
+func TestStreamUsersTopicAccuracyCancelled(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	mustCreate(t, db, outcomes(user, 1, 0, true))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out := make(chan UserTopicRow)
+	err := StreamUsersTopicAccuracy(ctx, db, []uuid.UUID{user}, out)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if _, open := <-out; open {
+		t.Fatal("out was not closed")
+	}
+}
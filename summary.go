@@ -0,0 +1,98 @@
+package main
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserTopicAccuracy is the stored per-topic summary for a user, as of
+// the last time SaveUserTopicAccuracy ran.
+type UserTopicAccuracy struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Topic     string    `gorm:"size:100;primaryKey"`
+	Total     int64
+	Correct   int64
+	Accuracy  float64
+	UpdatedAt time.Time
+}
+
+// SaveUserTopicAccuracy recomputes the user's per-topic accuracy and
+// overwrites their stored summary rows with it.
+func SaveUserTopicAccuracy(db *gorm.DB, userID uuid.UUID) error {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return err
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	rows := make([]UserTopicAccuracy, len(counts))
+	for i, c := range counts {
+		rows[i] = UserTopicAccuracy{
+			UserID:   userID,
+			Topic:    c.Topic,
+			Total:    c.Total,
+			Correct:  c.Correct,
+			Accuracy: c.accuracy(),
+		}
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error
+}
+
+// Directions reported in TopicAccuracyTrend.
+const (
+	TrendUp   = "up"
+	TrendDown = "down"
+	TrendFlat = "flat"
+	TrendNew  = "new"
+)
+
+// TopicAccuracyTrend is a topic's live accuracy% and how it moved since
+// the stored summary.
+type TopicAccuracyTrend struct {
+	Accuracy  float64
+	Delta     float64
+	Direction string
+}
+
+// CalculateUserTopicAccuracyWithTrend returns live per-topic accuracy%
+// alongside the change in percentage points from the stored
+// UserTopicAccuracy summary. Topics without a stored summary report a
+// Delta of 0 and Direction TrendNew.
+func CalculateUserTopicAccuracyWithTrend(db *gorm.DB, userID uuid.UUID) (map[string]TopicAccuracyTrend, error) {
+	counts, err := scanTopicCounts(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []UserTopicAccuracy
+	if err := db.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	previous := make(map[string]float64, len(stored))
+	for _, s := range stored {
+		previous[s.Topic] = s.Accuracy
+	}
+
+	trends := make(map[string]TopicAccuracyTrend, len(counts))
+	for topic, acc := range accuracyByTopic(counts) {
+		trend := TopicAccuracyTrend{Accuracy: acc, Direction: TrendNew}
+		if prev, ok := previous[topic]; ok {
+			trend.Delta = acc - prev
+			switch {
+			case trend.Delta > 0:
+				trend.Direction = TrendUp
+			case trend.Delta < 0:
+				trend.Direction = TrendDown
+			default:
+				trend.Direction = TrendFlat
+			}
+		}
+		trends[topic] = trend
+	}
+	return trends, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateUserTopicAccuracyWithTrend(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus", "Biology")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+	mustCreate(t, db, outcomes(user, 3, 20, true))
+	if err := SaveUserTopicAccuracy(db, user); err != nil {
+		t.Fatal(err)
+	}
+
+	mustCreate(t, db, outcomes(user, 1, 30, true, true))
+	mustCreate(t, db, outcomes(user, 2, 40, false))
+	mustCreate(t, db, outcomes(user, 4, 50, true))
+
+	got, err := CalculateUserTopicAccuracyWithTrend(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]TopicAccuracyTrend{
+		"Algebra":  {Accuracy: 75, Delta: 25, Direction: TrendUp},
+		"Geometry": {Accuracy: 50, Delta: -50, Direction: TrendDown},
+		"Calculus": {Accuracy: 100, Delta: 0, Direction: TrendFlat},
+		"Biology":  {Accuracy: 100, Delta: 0, Direction: TrendNew},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for topic, w := range want {
+		g := got[topic]
+		if !approxEqual(g.Accuracy, w.Accuracy) || !approxEqual(g.Delta, w.Delta) || g.Direction != w.Direction {
+			t.Fatalf("%s: got %+v, want %+v", topic, g, w)
+		}
+	}
+
+	// Saving again overwrites rather than duplicating the summary.
+	if err := SaveUserTopicAccuracy(db, user); err != nil {
+		t.Fatal(err)
+	}
+	var stored []UserTopicAccuracy
+	if err := db.Where("user_id = ?", user).Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 4 {
+		t.Fatalf("stored %d rows, want 4", len(stored))
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testEpoch is a fixed Monday morning, UTC, that test data is laid out
+// around.
+var testEpoch = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// newTestDB returns a fresh in-memory SQLite database with every model
+// migrated, configured like main. A single connection keeps all queries
+// on the same database.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger:  logger.Discard,
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(
+		&Question{}, &QuestionAttempt{}, &Session{},
+		&UserTopicAccuracy{}, &TopicAccuracySnapshot{},
+	)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// mustCreate inserts value, failing the test on error.
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// seedTopics creates one question per topic, with IDs 1, 2, … in order.
+func seedTopics(t *testing.T, db *gorm.DB, topics ...string) {
+	t.Helper()
+	questions := make([]Question, len(topics))
+	for i, topic := range topics {
+		questions[i] = Question{ID: uint(i + 1), Topic: topic}
+	}
+	mustCreate(t, db, &questions)
+}
+
+// attempt builds an attempt by userID at questionID made minutes after
+// testEpoch.
+func attempt(userID uuid.UUID, questionID uint, correct bool, minutes int) QuestionAttempt {
+	return QuestionAttempt{
+		UserID:     userID,
+		QuestionID: questionID,
+		IsCorrect:  correct,
+		CreatedAt:  testEpoch.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+// outcomes builds attempts by userID at questionID, one minute apart
+// starting at minute start, with the given correctness.
+func outcomes(userID uuid.UUID, questionID uint, start int, correct ...bool) []QuestionAttempt {
+	attempts := make([]QuestionAttempt, len(correct))
+	for i, ok := range correct {
+		attempts[i] = attempt(userID, questionID, ok, start+i)
+	}
+	return attempts
+}
+
+// approxEqual reports whether a and b agree to within 1e-6.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+// assertAccuracies fails unless got has exactly want's keys with
+// approximately equal values.
+func assertAccuracies(t *testing.T, got, want map[string]float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, w := range want {
+		g, ok := got[k]
+		if !ok || !approxEqual(g, w) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// results returns total outcomes of which the first correct are right.
+func results(correct, total int) []bool {
+	rs := make([]bool, total)
+	for i := 0; i < correct; i++ {
+		rs[i] = true
+	}
+	return rs
+}
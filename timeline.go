@@ -0,0 +1,431 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalculateUserTopicSparkline returns, per topic, the user's running
+// accuracy% downsampled to at most points values. Series already that
+// short are returned whole; longer ones are sampled at evenly spaced
+// attempt indexes that always include the first and latest attempt
+// (a single point is the latest).
+func CalculateUserTopicSparkline(db *gorm.DB, userID uuid.UUID, points int) (map[string][]float64, error) {
+	if points < 1 {
+		return nil, ErrInvalidPoints
+	}
+
+	outcomes, err := userTopicOutcomes(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sparklines := make(map[string][]float64, len(outcomes))
+	for topic, results := range outcomes {
+		sparklines[topic] = downsample(runningAccuracy(results), points)
+	}
+	return sparklines, nil
+}
+
+// downsample picks at most points evenly spaced values from series,
+// keeping both ends.
+func downsample(series []float64, points int) []float64 {
+	n := len(series)
+	if n <= points {
+		return series
+	}
+	if points == 1 {
+		return []float64{series[n-1]}
+	}
+
+	sampled := make([]float64, points)
+	for i := range sampled {
+		sampled[i] = series[i*(n-1)/(points-1)]
+	}
+	return sampled
+}
+
+// BeforeAfterAccuracy is a topic's accuracy% on either side of a
+// boundary. A side with no attempts is nil.
+type BeforeAfterAccuracy struct {
+	Before *float64
+	After  *float64
+}
+
+// CalculateUserTopicAccuracyBeforeAfter returns per-topic accuracy% for
+// attempts made before boundary and for those made at or after it.
+// Topics attempted on only one side report nil for the other.
+// boundary is compared in UTC, since SQLite compares stored times as
+// text.
+func CalculateUserTopicAccuracyBeforeAfter(db *gorm.DB, userID uuid.UUID, boundary time.Time) (map[string]BeforeAfterAccuracy, error) {
+	type Result struct {
+		Topic   string
+		After   bool
+		Total   int64
+		Correct int64
+	}
+
+	var results []Result
+	err := userAttempts(db, userID).
+		Select(`
+			questions.topic                                                AS topic,
+			question_attempts.created_at >= ?                              AS after,
+			COUNT(*)                                                       AS total,
+			SUM(CASE WHEN question_attempts.is_correct THEN 1 ELSE 0 END)  AS correct
+		`, boundary.UTC()).
+		Group("questions.topic, after").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	split := make(map[string]BeforeAfterAccuracy)
+	for _, r := range results {
+		s := split[r.Topic]
+		acc := topicCount{Total: r.Total, Correct: r.Correct}.accuracy()
+		if r.After {
+			s.After = &acc
+		} else {
+			s.Before = &acc
+		}
+		split[r.Topic] = s
+	}
+	return split, nil
+}
+
+// CalculateUserTopicDailyDelta returns, per topic practised on day, how
+// many percentage points the user's cumulative accuracy moved between
+// the start and end of that day. The day runs from midnight to midnight
+// in day's location. Topics not practised that day, topics first
+// practised that day (no starting value), and topics whose accuracy
+// ended the day where it started are omitted.
+func CalculateUserTopicDailyDelta(db *gorm.DB, userID uuid.UUID, day time.Time) (map[string]float64, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	before, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.created_at < ?", start.UTC()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	after, err := scanTopicCounts(
+		userAttempts(db, userID).Where("question_attempts.created_at < ?", end.UTC()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := accuracyDeltas(before, after)
+	for topic, d := range deltas {
+		if d == 0 {
+			delete(deltas, topic)
+		}
+	}
+	return deltas, nil
+}
+
+// Time buckets accepted by the timeline calculations.
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// bucketStart truncates t, in UTC, to the start of its bucket. Weeks
+// start on Monday.
+func bucketStart(t time.Time, bucket string) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case BucketDay:
+		return day, nil
+	case BucketWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset), nil
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, ErrInvalidBucket
+}
+
+// TopicBreadthPoint is the number of distinct topics practised in the
+// week starting at Week.
+type TopicBreadthPoint struct {
+	Week           time.Time
+	DistinctTopics int
+}
+
+// CalculateUserTopicBreadthTimeline returns, for each week the user
+// practised, how many distinct topics they attempted, oldest week
+// first. Weeks start on Monday, UTC; weeks with no attempts are omitted.
+func CalculateUserTopicBreadthTimeline(db *gorm.DB, userID uuid.UUID) ([]TopicBreadthPoint, error) {
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	topicsByWeek := make(map[time.Time]map[string]bool)
+	for _, r := range records {
+		week, _ := bucketStart(r.CreatedAt, BucketWeek)
+		if topicsByWeek[week] == nil {
+			topicsByWeek[week] = make(map[string]bool)
+		}
+		topicsByWeek[week][r.Topic] = true
+	}
+
+	timeline := make([]TopicBreadthPoint, 0, len(topicsByWeek))
+	for week, topics := range topicsByWeek {
+		timeline = append(timeline, TopicBreadthPoint{Week: week, DistinctTopics: len(topics)})
+	}
+	sort.Slice(timeline, func(i, j int) bool { return timeline[i].Week.Before(timeline[j].Week) })
+	return timeline, nil
+}
+
+// TrendPoint is the user's accuracy% over the bucket starting at
+// PeriodStart.
+type TrendPoint struct {
+	PeriodStart time.Time
+	Total       int64
+	Correct     int64
+	Accuracy    float64
+}
+
+// CalculateUserAccuracyTrend returns the user's overall accuracy% per
+// day, week or month, oldest first. Buckets are in UTC and buckets
+// without attempts are omitted rather than reported as 0.
+func CalculateUserAccuracyTrend(db *gorm.DB, userID uuid.UUID, bucket string) ([]TrendPoint, error) {
+	if _, err := bucketStart(time.Time{}, bucket); err != nil {
+		return nil, err
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	return bucketTrend(records, bucket), nil
+}
+
+// bucketTrend tallies records, which must be oldest first, into one
+// TrendPoint per non-empty bucket. bucket must already be validated.
+func bucketTrend(records []attemptRecord, bucket string) []TrendPoint {
+	var trend []TrendPoint
+	for _, r := range records {
+		start, _ := bucketStart(r.CreatedAt, bucket)
+		if n := len(trend); n == 0 || !trend[n-1].PeriodStart.Equal(start) {
+			trend = append(trend, TrendPoint{PeriodStart: start})
+		}
+		p := &trend[len(trend)-1]
+		p.Total++
+		if r.IsCorrect {
+			p.Correct++
+		}
+	}
+	for i := range trend {
+		trend[i].Accuracy = topicCount{Total: trend[i].Total, Correct: trend[i].Correct}.accuracy()
+	}
+	return trend
+}
+
+// CalculateUserAccuracyTrendSmoothed returns CalculateUserAccuracyTrend
+// with each point's Accuracy replaced by a centered moving average over
+// window points. Near either end the window is truncated to the points
+// that exist, so the series keeps its length and the end points are
+// averaged over fewer neighbours. An even window reaches one point
+// further back than forward. Total and Correct are left raw.
+func CalculateUserAccuracyTrendSmoothed(db *gorm.DB, userID uuid.UUID, bucket string, window int) ([]TrendPoint, error) {
+	if window < 1 {
+		return nil, ErrInvalidWindow
+	}
+
+	raw, err := CalculateUserAccuracyTrend(db, userID, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	back, forward := window/2, (window-1)/2
+	smoothed := make([]TrendPoint, len(raw))
+	for i := range raw {
+		lo, hi := i-back, i+forward
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(raw)-1 {
+			hi = len(raw) - 1
+		}
+		var sum float64
+		for _, p := range raw[lo : hi+1] {
+			sum += p.Accuracy
+		}
+		smoothed[i] = raw[i]
+		smoothed[i].Accuracy = sum / float64(hi-lo+1)
+	}
+	return smoothed, nil
+}
+
+// CalculateUserTopicPeakAccuracy returns, per topic, the highest
+// accuracy% the user achieved within any windowDays-long stretch. Each
+// window starts at one of the topic's attempts and covers every attempt
+// made in the following windowDays. No minimum size is imposed, so a
+// lone correct attempt is a 100% window.
+func CalculateUserTopicPeakAccuracy(db *gorm.DB, userID uuid.UUID, windowDays int) (map[string]float64, error) {
+	if windowDays < 1 {
+		return nil, ErrInvalidWindow
+	}
+	window := time.Duration(windowDays) * 24 * time.Hour
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	byTopic := recordsByTopic(records)
+
+	peaks := make(map[string]float64, len(byTopic))
+	for topic, rs := range byTopic {
+		var best float64
+		end, correct := 0, 0
+		for start := range rs {
+			for end < len(rs) && rs[end].CreatedAt.Before(rs[start].CreatedAt.Add(window)) {
+				if rs[end].IsCorrect {
+					correct++
+				}
+				end++
+			}
+			if acc := float64(correct) * 100 / float64(end-start); acc > best {
+				best = acc
+			}
+			if rs[start].IsCorrect {
+				correct--
+			}
+		}
+		peaks[topic] = best
+	}
+	return peaks, nil
+}
+
+// CalculateUserTopicRetention returns, per topic, the share (0–1) of an
+// improvement that survived a break from practice.
+//
+// The break is the topic's most recent pair of consecutive attempts at
+// least gapDays apart. The attempts before it are split into an early
+// and a late half; the gain is late accuracy minus early accuracy. The
+// same number of attempts as the late half (or fewer, if that's all
+// there is) right after the break measure what is left, and retention
+// is (after - early) / gain, clamped to [0, 1]. Topics without such a
+// break, or that did not improve before it, are omitted.
+func CalculateUserTopicRetention(db *gorm.DB, userID uuid.UUID, gapDays int) (map[string]float64, error) {
+	if gapDays < 1 {
+		return nil, ErrInvalidWindow
+	}
+	gap := time.Duration(gapDays) * 24 * time.Hour
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	byTopic := recordsByTopic(records)
+
+	retention := make(map[string]float64)
+	for topic, rs := range byTopic {
+		split := -1
+		for i := len(rs) - 1; i > 0; i-- {
+			if rs[i].CreatedAt.Sub(rs[i-1].CreatedAt) >= gap {
+				split = i
+				break
+			}
+		}
+		if split < 2 {
+			continue
+		}
+
+		before, after := rs[:split], rs[split:]
+		early := recordsAccuracy(before[:len(before)/2])
+		lateRecords := before[len(before)/2:]
+		gain := recordsAccuracy(lateRecords) - early
+		if gain <= 0 {
+			continue
+		}
+		if len(after) > len(lateRecords) {
+			after = after[:len(lateRecords)]
+		}
+		retention[topic] = clamp((recordsAccuracy(after)-early)/gain, 0, 1)
+	}
+	return retention, nil
+}
+
+// recordsAccuracy returns the accuracy% of records.
+func recordsAccuracy(records []attemptRecord) float64 {
+	var c topicCount
+	for _, r := range records {
+		c.Total++
+		if r.IsCorrect {
+			c.Correct++
+		}
+	}
+	return c.accuracy()
+}
+
+// CalculateUserTopicAccuracyTrend is CalculateUserAccuracyTrend broken
+// out per topic: each topic gets its own oldest-first series of UTC
+// buckets, and, as in the overall trend, buckets in which the topic was
+// not attempted are omitted.
+func CalculateUserTopicAccuracyTrend(db *gorm.DB, userID uuid.UUID, bucket string) (map[string][]TrendPoint, error) {
+	if _, err := bucketStart(time.Time{}, bucket); err != nil {
+		return nil, err
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, userID))
+	if err != nil {
+		return nil, err
+	}
+	byTopic := recordsByTopic(records)
+
+	trends := make(map[string][]TrendPoint, len(byTopic))
+	for topic, rs := range byTopic {
+		trends[topic] = bucketTrend(rs, bucket)
+	}
+	return trends, nil
+}
+
+// BandedTrendPoint is a TrendPoint's accuracy% with the bounds of its
+// Wilson score interval.
+type BandedTrendPoint struct {
+	PeriodStart time.Time
+	Accuracy    float64
+	Low         float64
+	High        float64
+}
+
+// CalculateUserAccuracyTrendWithBands returns CalculateUserAccuracyTrend
+// with a Wilson score interval at z-score z (1.96 for a 95% band) around
+// each point, as percentages. Every bucket gets a band:
+// Wilson intervals stay valid for tiny samples but widen sharply, so a
+// bucket with one or two attempts shows a very wide band rather than
+// none. Low <= Accuracy <= High always holds. It returns
+// ErrInvalidConfidence if z is not positive.
+func CalculateUserAccuracyTrendWithBands(db *gorm.DB, userID uuid.UUID, bucket string, z float64) ([]BandedTrendPoint, error) {
+	if z <= 0 {
+		return nil, ErrInvalidConfidence
+	}
+
+	trend, err := CalculateUserAccuracyTrend(db, userID, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	banded := make([]BandedTrendPoint, len(trend))
+	for i, p := range trend {
+		low, high := wilsonInterval(p.Correct, p.Total, z)
+		banded[i] = BandedTrendPoint{
+			PeriodStart: p.PeriodStart,
+			Accuracy:    p.Accuracy,
+			Low:         math.Min(low*100, p.Accuracy),
+			High:        math.Max(high*100, p.Accuracy),
+		}
+	}
+	return banded, nil
+}
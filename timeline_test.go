@@ -0,0 +1,322 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// assertSeries fails unless got and want have the same length and
+// approximately equal values.
+func assertSeries(t *testing.T, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if !approxEqual(got[i], want[i]) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCalculateUserTopicSparkline(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, false, true))
+
+	got, err := CalculateUserTopicSparkline(db, user, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Running accuracy 100, 50, 66.7, 75, 60 sampled at attempts 1, 3, 5.
+	assertSeries(t, got["Algebra"], []float64{100, 200.0 / 3, 60})
+	assertSeries(t, got["Geometry"], []float64{0, 50})
+
+	got, err = CalculateUserTopicSparkline(db, user, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSeries(t, got["Algebra"], []float64{60})
+
+	if _, err := CalculateUserTopicSparkline(db, user, 0); !errors.Is(err, ErrInvalidPoints) {
+		t.Fatalf("got %v, want ErrInvalidPoints", err)
+	}
+}
+
+func TestCalculateUserTopicAccuracyBeforeAfter(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 1, 60, true, true, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, true))
+
+	// The boundary falls half an hour in, given in a non-UTC zone.
+	boundary := testEpoch.Add(30 * time.Minute).In(time.FixedZone("UTC-5", -5*60*60))
+	got, err := CalculateUserTopicAccuracyBeforeAfter(db, user, boundary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %v, want two topics", got)
+	}
+	algebra, geometry := got["Algebra"], got["Geometry"]
+	if algebra.Before == nil || !approxEqual(*algebra.Before, 50) || algebra.After == nil || !approxEqual(*algebra.After, 75) {
+		t.Fatalf("Algebra: got %+v, want 50 before and 75 after", algebra)
+	}
+	if geometry.Before == nil || !approxEqual(*geometry.Before, 100) || geometry.After != nil {
+		t.Fatalf("Geometry: got %+v, want 100 before and nothing after", geometry)
+	}
+}
+
+func TestCalculateUserTopicAccuracyBeforeAfterMixedZones(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// Either attempt's local clock reading is on the wrong side of the
+	// boundary; only their instants count.
+	east, west := time.FixedZone("UTC+5", 5*60*60), time.FixedZone("UTC-5", -5*60*60)
+	mustCreate(t, db, &[]QuestionAttempt{
+		{UserID: user, QuestionID: 1, IsCorrect: true, CreatedAt: testEpoch.Add(-30 * time.Minute).In(east)},
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: testEpoch.Add(30 * time.Minute).In(west)},
+	})
+
+	got, err := CalculateUserTopicAccuracyBeforeAfter(db, user, testEpoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	algebra := got["Algebra"]
+	if algebra.Before == nil || !approxEqual(*algebra.Before, 100) || algebra.After == nil || !approxEqual(*algebra.After, 0) {
+		t.Fatalf("Algebra: got %+v, want 100 before and 0 after", algebra)
+	}
+}
+
+func TestCalculateUserTopicDailyDelta(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus", "Biology")
+	// 2 January at UTC+5 runs from 19:00 UTC on 1 January, ten hours
+	// after testEpoch.
+	day := time.Date(2024, 1, 2, 12, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60))
+	during := 12 * 60
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 1, during, true, true))
+	mustCreate(t, db, outcomes(user, 2, 10, true, false))
+	mustCreate(t, db, outcomes(user, 2, during+10, true, false)) // unchanged
+	mustCreate(t, db, outcomes(user, 3, during+20, true))        // no baseline
+	mustCreate(t, db, outcomes(user, 4, 20, true))               // not practised
+
+	got, err := CalculateUserTopicDailyDelta(db, user, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 25})
+}
+
+func TestCalculateUserTopicDailyDeltaMixedZones(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// 2 January UTC. The attempt just before midnight is stored at UTC+5
+	// and the one just after at UTC-5, so neither local date is its UTC
+	// date.
+	day := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	east, west := time.FixedZone("UTC+5", 5*60*60), time.FixedZone("UTC-5", -5*60*60)
+	mustCreate(t, db, &[]QuestionAttempt{
+		{UserID: user, QuestionID: 1, IsCorrect: true, CreatedAt: midnight.Add(-time.Minute).In(east)},
+		{UserID: user, QuestionID: 1, IsCorrect: false, CreatedAt: midnight.Add(time.Minute).In(west)},
+	})
+
+	got, err := CalculateUserTopicDailyDelta(db, user, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": -50})
+}
+
+func TestCalculateUserTopicBreadthTimeline(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	const day = 24 * 60
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, true, 0),
+		attempt(user, 1, true, 1),
+		attempt(user, 2, false, 6*day), // Sunday, same week
+		attempt(user, 1, true, 14*day),
+	})
+
+	got, err := CalculateUserTopicBreadthTimeline(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TopicBreadthPoint{
+		{Week: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), DistinctTopics: 2},
+		{Week: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), DistinctTopics: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Week.Equal(want[i].Week) || got[i].DistinctTopics != want[i].DistinctTopics {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCalculateUserAccuracyTrendSmoothed(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	const day = 24 * 60
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 1, day, true))
+	mustCreate(t, db, outcomes(user, 1, 3*day, false, false))
+
+	raw, err := CalculateUserAccuracyTrend(db, user, BucketDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The empty day in between is omitted.
+	if len(raw) != 3 || !raw[2].PeriodStart.Equal(time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)) || raw[0].Total != 2 {
+		t.Fatalf("got %+v", raw)
+	}
+	accs := make([]float64, len(raw))
+	for i, p := range raw {
+		accs[i] = p.Accuracy
+	}
+	assertSeries(t, accs, []float64{50, 100, 0})
+
+	smoothed, err := CalculateUserAccuracyTrendSmoothed(db, user, BucketDay, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range smoothed {
+		accs[i] = p.Accuracy
+	}
+	assertSeries(t, accs, []float64{75, 50, 50})
+
+	if _, err := CalculateUserAccuracyTrend(db, user, "year"); !errors.Is(err, ErrInvalidBucket) {
+		t.Fatalf("got %v, want ErrInvalidBucket", err)
+	}
+	if _, err := CalculateUserAccuracyTrendSmoothed(db, user, BucketDay, 0); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("got %v, want ErrInvalidWindow", err)
+	}
+}
+
+func TestCalculateUserTopicPeakAccuracy(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	const day = 24 * 60
+	mustCreate(t, db, &[]QuestionAttempt{
+		attempt(user, 1, false, 0),
+		attempt(user, 1, true, day),
+		attempt(user, 1, true, day+day/2),
+		attempt(user, 1, false, 2*day+day/2),
+		attempt(user, 2, true, 0),
+	})
+
+	// Two-day windows hold at best 2 of 3 right; a lone correct
+	// attempt is a 100% window.
+	got, err := CalculateUserTopicPeakAccuracy(db, user, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 200.0 / 3, "Geometry": 100})
+
+	if _, err := CalculateUserTopicPeakAccuracy(db, user, 0); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("got %v, want ErrInvalidWindow", err)
+	}
+}
+
+func TestCalculateUserTopicRetention(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry", "Calculus")
+	const day = 24 * 60
+	mustCreate(t, db, outcomes(user, 1, 0, false, false, true, true))
+	mustCreate(t, db, outcomes(user, 1, 10*day, true, false, true))
+	mustCreate(t, db, outcomes(user, 2, 0, false, true, true)) // no break
+	mustCreate(t, db, outcomes(user, 3, 0, true, true))        // no gain
+	mustCreate(t, db, outcomes(user, 3, 10*day, false))
+
+	// Algebra rose from 0% to 100% and came back at 50% over the first
+	// two attempts after the break.
+	got, err := CalculateUserTopicRetention(db, user, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, got, map[string]float64{"Algebra": 0.5})
+
+	if _, err := CalculateUserTopicRetention(db, user, 0); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("got %v, want ErrInvalidWindow", err)
+	}
+}
+
+func TestCalculateUserTopicAccuracyTrend(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra", "Geometry")
+	const week = 7 * 24 * 60
+	mustCreate(t, db, outcomes(user, 1, 0, true, false))
+	mustCreate(t, db, outcomes(user, 2, 10, false))
+	mustCreate(t, db, outcomes(user, 1, 2*week, true))
+
+	got, err := CalculateUserTopicAccuracyTrend(db, user, BucketWeek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	algebra, geometry := got["Algebra"], got["Geometry"]
+	if len(got) != 2 || len(algebra) != 2 || len(geometry) != 1 {
+		t.Fatalf("got %+v", got)
+	}
+	// The week Algebra skipped is omitted, not reported as 0.
+	if !algebra[1].PeriodStart.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) ||
+		!approxEqual(algebra[0].Accuracy, 50) || !approxEqual(algebra[1].Accuracy, 100) ||
+		!approxEqual(geometry[0].Accuracy, 0) {
+		t.Fatalf("got %+v", got)
+	}
+
+	if _, err := CalculateUserTopicAccuracyTrend(db, user, "hour"); !errors.Is(err, ErrInvalidBucket) {
+		t.Fatalf("got %v, want ErrInvalidBucket", err)
+	}
+}
+
+func TestCalculateUserAccuracyTrendWithBands(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	mustCreate(t, db, outcomes(user, 1, 0, results(10, 10)...))
+	mustCreate(t, db, outcomes(user, 1, 24*60, false))
+
+	got, err := CalculateUserAccuracyTrendWithBands(db, user, BucketDay, 1.96)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// At the extremes the Wilson bound is n / (n + z²) from the far side.
+	z2 := 1.96 * 1.96
+	want := []BandedTrendPoint{
+		{Accuracy: 100, Low: 10 / (10 + z2) * 100, High: 100},
+		{Accuracy: 0, Low: 0, High: z2 / (1 + z2) * 100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i, w := range want {
+		g := got[i]
+		if !approxEqual(g.Accuracy, w.Accuracy) || !approxEqual(g.Low, w.Low) || !approxEqual(g.High, w.High) {
+			t.Fatalf("point %d: got %+v, want %+v", i, g, w)
+		}
+	}
+
+	if _, err := CalculateUserAccuracyTrendWithBands(db, user, BucketDay, 0); !errors.Is(err, ErrInvalidConfidence) {
+		t.Fatalf("got %v, want ErrInvalidConfidence", err)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// attemptOrder orders attempts chronologically. Batch inserts can give
+// several attempts the same created_at, so ties fall back to the
+// attempt ID; every "first", "latest" or running calculation must use
+// it (or attemptOrderDesc) to stay reproducible.
+const (
+	attemptOrder     = "question_attempts.created_at, question_attempts.id"
+	attemptOrderDesc = "question_attempts.created_at DESC, question_attempts.id DESC"
+)
+
+// topicCount holds the raw per-topic totals most accuracy
+// calculations are derived from.
+type topicCount struct {
+	Topic   string
+	Total   int64
+	Correct int64
+}
+
+// accuracy returns the share of correct attempts as a percentage.
+func (c topicCount) accuracy() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(c.Correct) * 100 / float64(c.Total)
+}
+
+// userAttempts starts a query over a user's attempts joined with
+// their questions, ready for further filtering and aggregation.
+func userAttempts(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.
+		Model(&QuestionAttempt{}).
+		Joins("JOIN questions ON questions.id = question_attempts.question_id").
+		Where("question_attempts.user_id = ?", userID)
+}
+
+// scanTopicCounts groups the attempts selected by q by topic and
+// returns the total and correct count for each.
+func scanTopicCounts(q *gorm.DB) ([]topicCount, error) {
+	return scanCountsBy(q, "questions.topic")
+}
+
+// scanCountsBy is scanTopicCounts for an arbitrary grouping: key is a
+// SQL expression whose value ends up in each row's Topic field.
+func scanCountsBy(q *gorm.DB, key string) ([]topicCount, error) {
+	var counts []topicCount
+	err := q.
+		Select(key + ` AS topic,
+			COUNT(*)                                                       AS total,
+			SUM(CASE WHEN question_attempts.is_correct THEN 1 ELSE 0 END)  AS correct
+		`).
+		Group(key).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// accuracyByTopic converts per-topic counts into a map[topic]accuracy%.
+func accuracyByTopic(counts []topicCount) map[string]float64 {
+	accuracies := make(map[string]float64, len(counts))
+	for _, c := range counts {
+		if c.Total > 0 {
+			accuracies[c.Topic] = c.accuracy()
+		}
+	}
+	return accuracies
+}
+
+// topicOutcomes returns the correctness of each of the user's attempts
+// in topic, oldest first.
+func topicOutcomes(db *gorm.DB, userID uuid.UUID, topic string) ([]bool, error) {
+	var outcomes []bool
+	err := userAttempts(db, userID).
+		Where("questions.topic = ?", topic).
+		Order(attemptOrder).
+		Pluck("question_attempts.is_correct", &outcomes).Error
+	if err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+// userTopicCount is a topicCount broken out per user.
+type userTopicCount struct {
+	UserID uuid.UUID
+	topicCount
+}
+
+// cohortAttempts starts a query over the attempts of every listed user
+// joined with their questions.
+func cohortAttempts(db *gorm.DB, userIDs []uuid.UUID) *gorm.DB {
+	return db.
+		Model(&QuestionAttempt{}).
+		Joins("JOIN questions ON questions.id = question_attempts.question_id").
+		Where("question_attempts.user_id IN ?", userIDs)
+}
+
+// scanUserTopicCounts groups the attempts selected by q by user and
+// topic and returns the total and correct count for each pair.
+func scanUserTopicCounts(q *gorm.DB) ([]userTopicCount, error) {
+	type Result struct {
+		UserID  uuid.UUID
+		Topic   string
+		Total   int64
+		Correct int64
+	}
+
+	var results []Result
+	err := selectUserTopicCounts(q).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]userTopicCount, len(results))
+	for i, r := range results {
+		counts[i] = userTopicCount{
+			UserID:     r.UserID,
+			topicCount: topicCount{Topic: r.Topic, Total: r.Total, Correct: r.Correct},
+		}
+	}
+	return counts, nil
+}
+
+// selectUserTopicCounts adds the per-user, per-topic aggregation to q.
+func selectUserTopicCounts(q *gorm.DB) *gorm.DB {
+	return q.
+		Select(`
+			question_attempts.user_id                                      AS user_id,
+			questions.topic                                                AS topic,
+			COUNT(*)                                                       AS total,
+			SUM(CASE WHEN question_attempts.is_correct THEN 1 ELSE 0 END)  AS correct
+		`).
+		Group("question_attempts.user_id, questions.topic")
+}
+
+// accuraciesByTopic collects each user's accuracy% into one slice per
+// topic, skipping users with no attempts in that topic.
+func accuraciesByTopic(counts []userTopicCount) map[string][]float64 {
+	byTopic := make(map[string][]float64)
+	for _, c := range counts {
+		if c.Total > 0 {
+			byTopic[c.Topic] = append(byTopic[c.Topic], c.accuracy())
+		}
+	}
+	return byTopic
+}
+
+// userTopicOutcomes returns the correctness of each of the user's
+// attempts grouped by topic, oldest first within each topic.
+func userTopicOutcomes(db *gorm.DB, userID uuid.UUID) (map[string][]bool, error) {
+	type Result struct {
+		Topic     string
+		IsCorrect bool
+	}
+
+	var results []Result
+	err := userAttempts(db, userID).
+		Select("questions.topic AS topic, question_attempts.is_correct AS is_correct").
+		Order(attemptOrder).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make(map[string][]bool)
+	for _, r := range results {
+		outcomes[r.Topic] = append(outcomes[r.Topic], r.IsCorrect)
+	}
+	return outcomes, nil
+}
+
+// runningAccuracy returns the cumulative accuracy% after each outcome.
+func runningAccuracy(outcomes []bool) []float64 {
+	series := make([]float64, len(outcomes))
+	correct := 0
+	for i, ok := range outcomes {
+		if ok {
+			correct++
+		}
+		series[i] = float64(correct) * 100 / float64(i+1)
+	}
+	return series
+}
+
+// attemptRecord is a single attempt flattened with its question's topic.
+type attemptRecord struct {
+	ID         uint
+	QuestionID uint
+	SessionID  uuid.UUID
+	Topic      string
+	IsCorrect  bool
+	CreatedAt  time.Time
+}
+
+// userAttemptRecords loads every attempt selected by q as an
+// attemptRecord, oldest first. q should come from userAttempts or
+// cohortAttempts.
+func userAttemptRecords(q *gorm.DB) ([]attemptRecord, error) {
+	var records []attemptRecord
+	err := q.
+		Select(`
+			question_attempts.id           AS id,
+			question_attempts.question_id  AS question_id,
+			question_attempts.session_id   AS session_id,
+			questions.topic                AS topic,
+			question_attempts.is_correct   AS is_correct,
+			question_attempts.created_at   AS created_at
+		`).
+		Order(attemptOrder).
+		Scan(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// recordsByTopic splits records by topic, keeping their order.
+func recordsByTopic(records []attemptRecord) map[string][]attemptRecord {
+	byTopic := make(map[string][]attemptRecord)
+	for _, r := range records {
+		byTopic[r.Topic] = append(byTopic[r.Topic], r)
+	}
+	return byTopic
+}
+
+// countByTopic tallies records into per-topic counts.
+func countByTopic(records []attemptRecord) []topicCount {
+	index := make(map[string]int)
+	var counts []topicCount
+	for _, r := range records {
+		i, ok := index[r.Topic]
+		if !ok {
+			i = len(counts)
+			index[r.Topic] = i
+			counts = append(counts, topicCount{Topic: r.Topic})
+		}
+		counts[i].Total++
+		if r.IsCorrect {
+			counts[i].Correct++
+		}
+	}
+	return counts
+}
+
+// accuracyDeltas returns after's accuracy% minus before's for every
+// topic whose attempt count grew between the two. Topics missing from
+// before have no baseline and are left out.
+func accuracyDeltas(before, after []topicCount) map[string]float64 {
+	baseline := make(map[string]topicCount, len(before))
+	for _, c := range before {
+		baseline[c.Topic] = c
+	}
+
+	deltas := make(map[string]float64)
+	for _, c := range after {
+		b, ok := baseline[c.Topic]
+		if !ok || c.Total == b.Total {
+			continue
+		}
+		deltas[c.Topic] = c.accuracy() - b.accuracy()
+	}
+	return deltas
+}
+
+// poolCounts merges counts whose topics map to the same key, summing
+// their totals. The merged rows carry the key as their Topic.
+func poolCounts(counts []topicCount, key func(topic string) string) []topicCount {
+	index := make(map[string]int)
+	var pooled []topicCount
+	for _, c := range counts {
+		k := key(c.Topic)
+		i, ok := index[k]
+		if !ok {
+			i = len(pooled)
+			index[k] = i
+			pooled = append(pooled, topicCount{Topic: k})
+		}
+		pooled[i].Total += c.Total
+		pooled[i].Correct += c.Correct
+	}
+	return pooled
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAttemptOrderBreaksTiesByID(t *testing.T) {
+	db := newTestDB(t)
+	user := uuid.New()
+	seedTopics(t, db, "Algebra")
+	// Three attempts recorded at the same instant, inserted out of ID
+	// order.
+	tied := make([]QuestionAttempt, 3)
+	for i, id := range []uint{3, 1, 2} {
+		tied[i] = attempt(user, 1, id == 2, 0)
+		tied[i].ID = id
+	}
+	for i := range tied {
+		mustCreate(t, db, &tied[i])
+	}
+
+	records, err := userAttemptRecords(userAttempts(db, user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]uint, len(records))
+	for i, r := range records {
+		ids[i] = r.ID
+	}
+	if want := []uint{1, 2, 3}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("records in order %v, want %v", ids, want)
+	}
+
+	// The first attempt is ID 1, which was wrong.
+	first, err := CalculateUserTopicTimedFirstAttemptAccuracy(db, user, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccuracies(t, first, map[string]float64{"Algebra": 0})
+
+	// The latest incorrect attempt is ID 3.
+	samples, err := CalculateUserTopicAccuracyWithSamples(db, user, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := samples["Algebra"].SampleAttemptIDs, []uint{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("samples %v, want %v", got, want)
+	}
+}